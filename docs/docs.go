@@ -24,6 +24,160 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/diff": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Сканирование изменений между двумя ref (например, для pull request): новые и исправленные уязвимости",
+                "parameters": [
+                    {
+                        "enum": [
+                            "github",
+                            "gitlab",
+                            "gitea",
+                            "bitbucket",
+                            "bitbucket-server",
+                            "azure",
+                            "git"
+                        ],
+                        "type": "string",
+                        "description": "Наименование сервиса",
+                        "name": "service",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Информация о репозитории и сравниваемых ref",
+                        "name": "diff_info",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.diffRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/models.VulnerabilityDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/history": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Поиск по истории lock-файлов коммитов, в которых уязвимость появилась и была исправлена",
+                "parameters": [
+                    {
+                        "enum": [
+                            "github"
+                        ],
+                        "type": "string",
+                        "description": "Наименование сервиса",
+                        "name": "service",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Информация о репозитории и Id уязвимости",
+                        "name": "history_info",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.historyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/models.VulnerabilityHistory"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Исчерпан лимит запросов к API git-сервиса, повторите после Retry-After"
+                    }
+                }
+            }
+        },
+        "/org": {
+            "post": {
+                "description": "Репозитории создаются или обновляются в БД и сканируются в фоне, в ответе возвращается список поставленных в очередь репозиториев.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Сканирование всех репозиториев организации или пользователя",
+                "parameters": [
+                    {
+                        "enum": [
+                            "github"
+                        ],
+                        "type": "string",
+                        "description": "Наименование сервиса",
+                        "name": "service",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Организация или пользователь и параметры сканирования",
+                        "name": "org_info",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.orgScanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "accepted",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.queuedRepo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Сервис не поддерживает получение списка репозиториев или не указан OwnerId"
+                    },
+                    "500": {
+                        "description": "Не удалось получить список репозиториев или сохранить ни одного из них"
+                    },
+                    "503": {
+                        "description": "Исчерпан лимит запросов к API git-сервиса, повторите после Retry-After"
+                    }
+                }
+            }
+        },
         "/parse": {
             "post": {
                 "consumes": [
@@ -36,7 +190,14 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "enum": [
-                            "github"
+                            "github",
+                            "gitlab",
+                            "gitea",
+                            "bitbucket",
+                            "bitbucket-server",
+                            "azure",
+                            "git",
+                            "local"
                         ],
                         "type": "string",
                         "description": "Наименование сервиса",
@@ -67,6 +228,79 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "503": {
+                        "description": "Исчерпан лимит запросов к API git-сервиса, повторите после Retry-After"
+                    }
+                }
+            }
+        },
+        "/upload": {
+            "post": {
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Сканирование загруженного архива (zip или tar.gz) на наличие уязвимостей в lock-файлах",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Id репозитория в БД",
+                        "name": "repoId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Архив с исходным кодом (.zip, .tar.gz, .tgz)",
+                        "name": "archive",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Glob-шаблоны путей lock-файлов, которые нужно сканировать (можно передать и в query)",
+                        "name": "Include",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Glob-шаблоны путей, которые нужно пропустить (можно передать и в query)",
+                        "name": "Exclude",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальная глубина вложенности папок (можно передать и в query)",
+                        "name": "MaxDepth",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/main.severityCounts"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
@@ -78,10 +312,40 @@ const docTemplate = `{
         "gitParser.UserInfo": {
             "type": "object",
             "properties": {
-                "id": {
-                    "description": "Id пользователя в БД",
+                "exclude": {
+                    "description": "Glob-шаблоны путей, которые нужно пропустить (необязательно, если не указаны - пропускаются node_modules, vendor, фикстуры и примеры)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "include": {
+                    "description": "Glob-шаблоны путей lock-файлов, которые нужно сканировать (необязательно, поддерживается \"**\")",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "login": {
+                    "description": "Логин для авторизации по паре логин/пароль (необязательно, например app password в Bitbucket)",
+                    "type": "string"
+                },
+                "maxDepth": {
+                    "description": "Максимальная глубина вложенности папок (необязательно, 0 - только корень)",
                     "type": "integer"
                 },
+                "path": {
+                    "description": "Путь до папки относительно LOCAL_SCAN_ROOT (для сервиса local)",
+                    "type": "string"
+                },
+                "project": {
+                    "description": "Проект внутри организации (необязательно, используется в Azure DevOps)",
+                    "type": "string"
+                },
+                "ref": {
+                    "description": "Ветка, тег или коммит (необязательно, иначе основная ветка)",
+                    "type": "string"
+                },
                 "repo": {
                     "description": "Наименование репозитория без указания владельца",
                     "type": "string"
@@ -91,7 +355,11 @@ const docTemplate = `{
                     "type": "integer"
                 },
                 "token": {
-                    "description": "Access token пользователя в сервисе git",
+                    "description": "Access token пользователя в сервисе git (для GitHub необязательно, если воркер настроен как GitHub App)",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "Адрес self-hosted инстанса git-сервиса (необязательно)",
                     "type": "string"
                 },
                 "user": {
@@ -100,6 +368,220 @@ const docTemplate = `{
                 }
             }
         },
+        "main.diffRequest": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "description": "Базовая ветка, тег или коммит (например, целевая ветка pull request)",
+                    "type": "string"
+                },
+                "exclude": {
+                    "description": "Glob-шаблоны путей, которые нужно пропустить (необязательно, если не указаны - пропускаются node_modules, vendor, фикстуры и примеры)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "head": {
+                    "description": "Проверяемая ветка, тег или коммит (например, ветка pull request)",
+                    "type": "string"
+                },
+                "include": {
+                    "description": "Glob-шаблоны путей lock-файлов, которые нужно сканировать (необязательно, поддерживается \"**\")",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "login": {
+                    "description": "Логин для авторизации по паре логин/пароль (необязательно, например app password в Bitbucket)",
+                    "type": "string"
+                },
+                "maxDepth": {
+                    "description": "Максимальная глубина вложенности папок (необязательно, 0 - только корень)",
+                    "type": "integer"
+                },
+                "path": {
+                    "description": "Путь до папки относительно LOCAL_SCAN_ROOT (для сервиса local)",
+                    "type": "string"
+                },
+                "project": {
+                    "description": "Проект внутри организации (необязательно, используется в Azure DevOps)",
+                    "type": "string"
+                },
+                "ref": {
+                    "description": "Ветка, тег или коммит (необязательно, иначе основная ветка)",
+                    "type": "string"
+                },
+                "repo": {
+                    "description": "Наименование репозитория без указания владельца",
+                    "type": "string"
+                },
+                "repoId": {
+                    "description": "Id репозитория в БД",
+                    "type": "integer"
+                },
+                "token": {
+                    "description": "Access token пользователя в сервисе git (для GitHub необязательно, если воркер настроен как GitHub App)",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "Адрес self-hosted инстанса git-сервиса (необязательно)",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "Имя пользователя в сервисе git",
+                    "type": "string"
+                }
+            }
+        },
+        "main.historyRequest": {
+            "type": "object",
+            "properties": {
+                "exclude": {
+                    "description": "Glob-шаблоны путей, которые нужно пропустить (необязательно, если не указаны - пропускаются node_modules, vendor, фикстуры и примеры)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "include": {
+                    "description": "Glob-шаблоны путей lock-файлов, которые нужно сканировать (необязательно, поддерживается \"**\")",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "login": {
+                    "description": "Логин для авторизации по паре логин/пароль (необязательно, например app password в Bitbucket)",
+                    "type": "string"
+                },
+                "maxDepth": {
+                    "description": "Максимальная глубина вложенности папок (необязательно, 0 - только корень)",
+                    "type": "integer"
+                },
+                "path": {
+                    "description": "Путь до папки относительно LOCAL_SCAN_ROOT (для сервиса local)",
+                    "type": "string"
+                },
+                "project": {
+                    "description": "Проект внутри организации (необязательно, используется в Azure DevOps)",
+                    "type": "string"
+                },
+                "ref": {
+                    "description": "Ветка, тег или коммит (необязательно, иначе основная ветка)",
+                    "type": "string"
+                },
+                "repo": {
+                    "description": "Наименование репозитория без указания владельца",
+                    "type": "string"
+                },
+                "repoId": {
+                    "description": "Id репозитория в БД",
+                    "type": "integer"
+                },
+                "token": {
+                    "description": "Access token пользователя в сервисе git (для GitHub необязательно, если воркер настроен как GitHub App)",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "Адрес self-hosted инстанса git-сервиса (необязательно)",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "Имя пользователя в сервисе git",
+                    "type": "string"
+                },
+                "vulnerabilityId": {
+                    "description": "Id уязвимости в OSV или её псевдоним (например, GHSA-... или CVE-...)",
+                    "type": "string"
+                }
+            }
+        },
+        "main.orgScanRequest": {
+            "type": "object",
+            "properties": {
+                "exclude": {
+                    "description": "Glob-шаблоны путей, которые нужно пропустить (необязательно, если не указаны - пропускаются node_modules, vendor, фикстуры и примеры)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "include": {
+                    "description": "Glob-шаблоны путей lock-файлов, которые нужно сканировать (необязательно, поддерживается \"**\")",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "login": {
+                    "description": "Логин для авторизации по паре логин/пароль (необязательно, например app password в Bitbucket)",
+                    "type": "string"
+                },
+                "maxDepth": {
+                    "description": "Максимальная глубина вложенности папок (необязательно, 0 - только корень)",
+                    "type": "integer"
+                },
+                "ownerId": {
+                    "description": "Id пользователя в БД, которому будут принадлежать репозитории",
+                    "type": "integer"
+                },
+                "path": {
+                    "description": "Путь до папки относительно LOCAL_SCAN_ROOT (для сервиса local)",
+                    "type": "string"
+                },
+                "project": {
+                    "description": "Проект внутри организации (необязательно, используется в Azure DevOps)",
+                    "type": "string"
+                },
+                "ref": {
+                    "description": "Ветка, тег или коммит (необязательно, иначе основная ветка)",
+                    "type": "string"
+                },
+                "repo": {
+                    "description": "Наименование репозитория без указания владельца",
+                    "type": "string"
+                },
+                "repoId": {
+                    "description": "Id репозитория в БД",
+                    "type": "integer"
+                },
+                "skipArchived": {
+                    "description": "Пропускать архивные репозитории",
+                    "type": "boolean"
+                },
+                "skipForks": {
+                    "description": "Пропускать форки",
+                    "type": "boolean"
+                },
+                "token": {
+                    "description": "Access token пользователя в сервисе git (для GitHub необязательно, если воркер настроен как GitHub App)",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "Адрес self-hosted инстанса git-сервиса (необязательно)",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "Имя пользователя в сервисе git",
+                    "type": "string"
+                }
+            }
+        },
+        "main.queuedRepo": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Наименование репозитория",
+                    "type": "string"
+                },
+                "repoId": {
+                    "description": "Id репозитория в БД",
+                    "type": "integer"
+                }
+            }
+        },
         "main.severityCounts": {
             "type": "object",
             "properties": {
@@ -113,6 +595,135 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "models.ExposureWindow": {
+            "type": "object",
+            "properties": {
+                "fixed_at": {
+                    "type": "string"
+                },
+                "fixed_commit": {
+                    "description": "Пусто, если уязвимость не исправлена",
+                    "type": "string"
+                },
+                "history_truncated": {
+                    "description": "Уязвимость могла появиться раньше самого старого просмотренного коммита",
+                    "type": "boolean"
+                },
+                "introduced_at": {
+                    "type": "string"
+                },
+                "introduced_commit": {
+                    "type": "string"
+                },
+                "packages": {
+                    "description": "Уязвимые пакеты в момент появления уязвимости",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PackageInfo"
+                    }
+                },
+                "source": {
+                    "$ref": "#/definitions/models.SourceInfo"
+                }
+            }
+        },
+        "models.PackageInfo": {
+            "type": "object",
+            "properties": {
+                "ecosystem": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SourceInfo": {
+            "type": "object",
+            "properties": {
+                "path": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.VulnerabilityChange": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_severity": {
+                    "type": "string"
+                },
+                "package": {
+                    "$ref": "#/definitions/models.PackageInfo"
+                },
+                "source": {
+                    "$ref": "#/definitions/models.SourceInfo"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "workspaces": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.VulnerabilityDiff": {
+            "type": "object",
+            "properties": {
+                "fixed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VulnerabilityChange"
+                    }
+                },
+                "introduced": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VulnerabilityChange"
+                    }
+                }
+            }
+        },
+        "models.VulnerabilityHistory": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "windows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExposureWindow"
+                    }
+                }
+            }
         }
     }
 }`
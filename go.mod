@@ -12,6 +12,7 @@ require (
 	github.com/swaggo/swag v1.16.3
 	golang.org/x/exp v0.0.0-20240506185415-9bf2ced13842
 	golang.org/x/sync v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -25,7 +26,6 @@ require (
 	github.com/swaggo/files v1.0.1 // indirect
 	golang.org/x/net v0.25.0 // indirect
 	golang.org/x/tools v0.21.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 require (
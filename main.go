@@ -3,11 +3,21 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
+	"sync"
+	"syscall"
+	"time"
 	"web-scan-worker/db"
 	"web-scan-worker/src/database"
 	"web-scan-worker/src/osvscanner"
@@ -20,105 +30,69 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/joho/godotenv"
 	httpSwagger "github.com/swaggo/http-swagger" // http-swagger middleware
+	"golang.org/x/sync/errgroup"
 )
 
+// Максимальный размер загружаемого архива
+const maxUploadSize = 200 << 20
+
+// Максимальный размер текстового поля формы загрузки архива
+const maxFormValueSize = 64 << 10
+
 type severityCounts struct {
 	Low      int
 	Moderate int
 	High     int
 }
 
-// @Summary			Парсинг git-репозитория для получения уязвимостей в lock-файлах
-// @Accept			json
-// @Produce			json
-// @Param			service			query		string						true	"Наименование сервиса" Enums(github)
-// @Param			user_info		body		gitParser.UserInfo			true	"Информация о пользователе и репозитории"
-// @Success			200				object		severityCounts				"ok"
-// @Failure			400
-// @Failure			404
-// @Failure			500
-// @Router			/parse [post]
-func parseRepo(w http.ResponseWriter, req *http.Request) {
-	fmt.Println("==================================")
-
-	// Получаем название сервиса
-	gitService := req.URL.Query().Get("service")
-
-	// Валидация допустимости сервиса
-	if gitService != "github" {
-		fmt.Println("Неподдерживаемый git-сервис")
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("Неподдерживаемый git-сервис"))
-		return
-	}
-
-	// Парсим body запроса
-	var userData gitParser.UserInfo
-	err := json.NewDecoder(req.Body).Decode(&userData)
-	if err != nil {
-		fmt.Println("Ошибка при декодировании:", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	fmt.Println("Репозиторий:", userData.User+"/"+userData.Repo)
-	fmt.Println()
-
+// Сканирование файлов на наличие уязвимостей и сохранение результата в БД.
+// scanParams - дополнительные поля записи о сканировании (происхождение, ветка, коммит).
+func scanFiles(files []gitParser.DepFile, repoId int, scanParams ...db.ScansSetParam) (severityCounts, error) {
 	client := database.PClient.Client
 	ctx := context.Background()
 
-	// Помечаем репозиторий, что он сканируется
-	client.Repos.FindMany(
-		db.Repos.ID.Equals(userData.RepoId),
-	).Update(
-		db.Repos.Status.Set(db.RepoStatusScanning),
-	).Exec(ctx)
-
-	// Получаем интересующие нас файлы
-	files, err := gitParser.GetFilesFromRepository(gitService, userData)
-	if err != nil {
-		fmt.Println("Ошибка при парсинге файлов:", err)
-		return
-	}
+	var counts severityCounts
 
 	// Создаём запись о сканировании
 	scan, err := client.Scans.CreateOne(
-		db.Scans.Repoitory.Link(db.Repos.ID.Equals(userData.RepoId)),
+		db.Scans.Repoitory.Link(db.Repos.ID.Equals(repoId)),
+		scanParams...,
 	).Exec(ctx)
 
 	if err != nil {
-		fmt.Println("Ошибка при создании Скана в БД:", err)
-		return
+		return counts, fmt.Errorf("ошибка при создании Скана в БД: %w", err)
 	}
 
-	var counts severityCounts
-	counts.Low = 0
-	counts.Moderate = 0
-	counts.High = 0
-
 	// Логгирование
 	if len(files) > 0 {
 		fmt.Println()
 		fmt.Println("Итоговый список lock-файлов:")
 		for _, file := range files {
-			fmt.Println("-", file.GetPath())
+			fmt.Println("-", file.Path)
 
 		}
 		fmt.Println()
 	}
 
 	// Сканируем файлы на наличие уязвимостей
-	results, err := osvscanner.DoScan(files, userData)
+	results, err := osvscanner.DoScan(files)
 	if err != nil {
-		fmt.Println("Ошибка при поиске уязвимостей:", err)
-		return
+		return counts, fmt.Errorf("ошибка при поиске уязвимостей: %w", err)
+	}
+
+	// Ошибки получения или разбора содержимого по путям источников
+	sourceErrors := map[string]string{}
+	for _, source := range files {
+		if source.Error != nil {
+			sourceErrors[source.Path] = source.Error.Error()
+		}
 	}
 
 	// Добавляем информацию об источниках, даже если в них нет уязвимых пакетов
 	for _, source := range files {
 		isExists := false
 		for _, res := range results.Results {
-			if res.Source.Path == source.GetPath() {
+			if res.Source.Path == source.Path {
 				isExists = true
 			}
 		}
@@ -126,8 +100,8 @@ func parseRepo(w http.ResponseWriter, req *http.Request) {
 		if !isExists {
 			results.Results = append(results.Results, models.PackageSource{
 				Source: models.SourceInfo{
-					Path: source.GetPath(),
-					Type: source.GetType(),
+					Path: source.Path,
+					Type: gitParser.SourceType(source.Name),
 				},
 				Packages: []models.PackageVulns{},
 			})
@@ -140,10 +114,20 @@ func parseRepo(w http.ResponseWriter, req *http.Request) {
 	for _, source := range results.Results {
 		fmt.Println("Источник", source.Source.Path)
 
-		// Создаём запись об источнике
+		// Создаём запись об источнике, сохраняя ошибку, если файл не удалось получить или разобрать
+		var sourceParams []db.SourcesSetParam
+		if sourceError, ok := sourceErrors[source.Source.Path]; ok {
+			fmt.Println("- Не удалось просканировать:", sourceError)
+			sourceParams = append(sourceParams, db.Sources.Error.Set(sourceError))
+		}
+
+		// Версии из манифеста не закреплены, это отмечается типом источника
+		sourceParams = append(sourceParams, db.Sources.Type.Set(source.Source.Type))
+
 		src, err := client.Sources.CreateOne(
 			db.Sources.Path.Set(source.Source.Path),
 			db.Sources.Scan.Link(db.Scans.ID.Equals(scan.ID)),
+			sourceParams...,
 		).Exec(ctx)
 
 		if err != nil {
@@ -264,7 +248,12 @@ func parseRepo(w http.ResponseWriter, req *http.Request) {
 				}
 			}
 
-			// Создаём запись о связи пакета и источника
+			// Создаём запись о связи пакета и источника с workspaces, которые зависят от пакета.
+			// Prisma не принимает nil для списка, поэтому без workspaces передаётся пустой список.
+			workspaces := pkg.Workspaces
+			if workspaces == nil {
+				workspaces = []string{}
+			}
 			_, err = client.PackagesInSources.CreateOne(
 				db.PackagesInSources.Packages.Link(
 					db.Packages.NameEcosystemVersion(
@@ -276,6 +265,7 @@ func parseRepo(w http.ResponseWriter, req *http.Request) {
 				db.PackagesInSources.Sources.Link(
 					db.Sources.ID.Equals(src.ID),
 				),
+				db.PackagesInSources.Workspaces.Set(workspaces),
 			).Exec(ctx)
 
 			if err != nil {
@@ -286,7 +276,7 @@ func parseRepo(w http.ResponseWriter, req *http.Request) {
 
 	// Помечаем репозиторий просканированным
 	client.Repos.FindMany(
-		db.Repos.ID.Equals(userData.RepoId),
+		db.Repos.ID.Equals(repoId),
 	).Update(
 		db.Repos.Status.Set(db.RepoStatusScanned),
 	).Exec(ctx)
@@ -305,6 +295,418 @@ func parseRepo(w http.ResponseWriter, req *http.Request) {
 		fmt.Println("Ошибка при попытке пометить репозиторий просканированным:", err)
 	}
 
+	return counts, nil
+}
+
+// Просканировать репозиторий и сохранить результат в БД.
+// Если сканирование прервалось, статус репозитория сбрасывается.
+func scanRepository(ctx context.Context, gitService string, userData gitParser.UserInfo) (severityCounts, error) {
+	client := database.PClient.Client
+	ctx = gitParser.WithRateLimits(ctx)
+
+	// Помечаем репозиторий, что он сканируется
+	client.Repos.FindMany(
+		db.Repos.ID.Equals(userData.RepoId),
+	).Update(
+		db.Repos.Status.Set(db.RepoStatusScanning),
+	).Exec(ctx)
+
+	// Фиксируем коммит, чтобы все файлы были взяты из одного состояния репозитория
+	ref, err := gitParser.ResolveRef(ctx, gitService, userData)
+	if err != nil {
+		resetRepoStatus(userData.RepoId)
+		return severityCounts{}, fmt.Errorf("ошибка при получении коммита: %w", err)
+	}
+
+	var scanParams []db.ScansSetParam
+	if ref.Ref != "" {
+		scanParams = append(scanParams, db.Scans.Branch.Set(ref.Ref))
+	}
+	if ref.Commit != "" {
+		fmt.Println("Коммит:", ref.Commit)
+		userData.Ref = ref.Commit
+		scanParams = append(scanParams, db.Scans.Commit.Set(ref.Commit))
+	}
+
+	// Получаем интересующие нас файлы
+	files, err := gitParser.GetFilesFromRepository(ctx, gitService, userData)
+	if err != nil {
+		resetRepoStatus(userData.RepoId)
+		return severityCounts{}, fmt.Errorf("ошибка при парсинге файлов: %w", err)
+	}
+
+	// Сохраняем оставшийся лимит запросов к API git-сервиса
+	if remaining, ok := gitParser.RateLimitRemaining(ctx, gitService, userData); ok {
+		fmt.Println("Осталось запросов к API:", remaining)
+		scanParams = append(scanParams, db.Scans.RateLimitRemaining.Set(remaining))
+	}
+
+	// Сканируем файлы и сохраняем результат
+	counts, err := scanFiles(files, userData.RepoId, scanParams...)
+	if err != nil {
+		resetRepoStatus(userData.RepoId)
+		return severityCounts{}, err
+	}
+
+	return counts, nil
+}
+
+// Вернуть репозиторию статус «не просканирован», если сканирование прервалось,
+// чтобы он не остался в статусе «сканируется»
+func resetRepoStatus(repoId int) {
+	_, err := database.PClient.Client.Repos.FindMany(
+		db.Repos.ID.Equals(repoId),
+	).Update(
+		db.Repos.Status.Set(db.RepoStatusNotScanned),
+	).Exec(context.Background())
+
+	if err != nil {
+		fmt.Println("Ошибка при попытке сбросить статус репозитория:", err)
+	}
+}
+
+// Ответить на ошибку получения файлов из git-сервиса.
+// При исчерпании лимита запросов сообщаем, когда сканирование можно повторить.
+func writeFetchError(w http.ResponseWriter, err error) {
+	var rateLimitErr *gitParser.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		retryAfter := int(math.Ceil(time.Until(rateLimitErr.Reset).Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
+// @Summary			Парсинг git-репозитория для получения уязвимостей в lock-файлах
+// @Accept			json
+// @Produce			json
+// @Param			service			query		string						true	"Наименование сервиса" Enums(github, gitlab, gitea, bitbucket, bitbucket-server, azure, git, local)
+// @Param			user_info		body		gitParser.UserInfo			true	"Информация о пользователе и репозитории"
+// @Success			200				object		severityCounts				"ok"
+// @Failure			400
+// @Failure			404
+// @Failure			500
+// @Failure			503				"Исчерпан лимит запросов к API git-сервиса, повторите после Retry-After"
+// @Router			/parse [post]
+func parseRepo(w http.ResponseWriter, req *http.Request) {
+	fmt.Println("==================================")
+
+	// Получаем название сервиса
+	gitService := req.URL.Query().Get("service")
+
+	// Валидация допустимости сервиса
+	if !gitParser.IsSupportedService(gitService) {
+		fmt.Println("Неподдерживаемый git-сервис")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Неподдерживаемый git-сервис"))
+		return
+	}
+
+	// Парсим body запроса
+	var userData gitParser.UserInfo
+	err := json.NewDecoder(req.Body).Decode(&userData)
+	if err != nil {
+		fmt.Println("Ошибка при декодировании:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	fmt.Println("Репозиторий:", userData.User+"/"+userData.Repo)
+	fmt.Println()
+
+	counts, err := scanRepository(req.Context(), gitService, userData)
+	if err != nil {
+		fmt.Println(err)
+		writeFetchError(w, err)
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Успех!")
+	fmt.Println("==================================")
+
+	// Возвращаем результат
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(counts)
+}
+
+// Запрос на сканирование изменений между двумя ref
+type diffRequest struct {
+	gitParser.UserInfo
+	Base string // Базовая ветка, тег или коммит (например, целевая ветка pull request)
+	Head string // Проверяемая ветка, тег или коммит (например, ветка pull request)
+}
+
+// @Summary			Сканирование изменений между двумя ref (например, для pull request): новые и исправленные уязвимости
+// @Accept			json
+// @Produce			json
+// @Param			service			query		string						true	"Наименование сервиса" Enums(github, gitlab, gitea, bitbucket, bitbucket-server, azure, git)
+// @Param			diff_info		body		diffRequest					true	"Информация о репозитории и сравниваемых ref"
+// @Success			200				object		models.VulnerabilityDiff	"ok"
+// @Failure			400
+// @Failure			500
+// @Router			/diff [post]
+func diffRepo(w http.ResponseWriter, req *http.Request) {
+	fmt.Println("==================================")
+
+	// Получаем название сервиса
+	gitService := req.URL.Query().Get("service")
+
+	// Валидация допустимости сервиса
+	if !gitParser.IsSupportedService(gitService) {
+		fmt.Println("Неподдерживаемый git-сервис")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Неподдерживаемый git-сервис"))
+		return
+	}
+
+	// Локальные папки worker'а не сравниваются: у них нет коммитов
+	if gitService == "local" {
+		fmt.Println("Сравнение недоступно для локальных папок")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Сравнение недоступно для локальных папок"))
+		return
+	}
+
+	// Парсим body запроса
+	var diffData diffRequest
+	err := json.NewDecoder(req.Body).Decode(&diffData)
+	if err != nil || diffData.Base == "" || diffData.Head == "" {
+		fmt.Println("Ошибка при декодировании:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	fmt.Println("Репозиторий:", diffData.User+"/"+diffData.Repo)
+	fmt.Println("Сравнение:", diffData.Base, "...", diffData.Head)
+	fmt.Println()
+
+	// Фиксируем коммиты, чтобы файлы были взяты из одного состояния репозитория
+	var commits []string
+	for _, ref := range []string{diffData.Base, diffData.Head} {
+		userData := diffData.UserInfo
+		userData.Ref = ref
+		info, err := gitParser.ResolveRef(req.Context(), gitService, userData)
+		if err != nil {
+			fmt.Println("Ошибка при получении коммита:", err)
+			writeFetchError(w, err)
+			return
+		}
+
+		commit := info.Commit
+		if commit == "" {
+			commit = ref
+		}
+		commits = append(commits, commit)
+	}
+
+	// Получаем изменённые файлы
+	changed, err := gitParser.GetChangedFiles(req.Context(), gitService, diffData.UserInfo, commits[0], commits[1])
+	if err != nil {
+		fmt.Println("Ошибка при получении изменённых файлов:", err)
+		writeFetchError(w, err)
+		return
+	}
+
+	// Сканируем обе версии файлов и сравниваем результаты
+	diff, err := osvscanner.DoDiffScan(changed)
+	if err != nil {
+		fmt.Println("Ошибка при поиске уязвимостей:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Новых уязвимостей:", len(diff.Introduced), "исправленных:", len(diff.Fixed))
+	fmt.Println("==================================")
+
+	// Возвращаем результат
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(diff)
+}
+
+// Запрос на поиск периодов, когда репозиторий был подвержен уязвимости
+type historyRequest struct {
+	gitParser.UserInfo
+	VulnerabilityId string // Id уязвимости в OSV или её псевдоним (например, GHSA-... или CVE-...)
+}
+
+// @Summary			Поиск по истории lock-файлов коммитов, в которых уязвимость появилась и была исправлена
+// @Accept			json
+// @Produce			json
+// @Param			service			query		string						true	"Наименование сервиса" Enums(github)
+// @Param			history_info	body		historyRequest				true	"Информация о репозитории и Id уязвимости"
+// @Success			200				object		models.VulnerabilityHistory	"ok"
+// @Failure			400
+// @Failure			500
+// @Failure			503				"Исчерпан лимит запросов к API git-сервиса, повторите после Retry-After"
+// @Router			/history [post]
+func historyRepo(w http.ResponseWriter, req *http.Request) {
+	fmt.Println("==================================")
+
+	// Получаем название сервиса
+	gitService := req.URL.Query().Get("service")
+
+	// Валидация допустимости сервиса
+	if !gitParser.CanGetHistory(gitService) {
+		fmt.Println("Git-сервис не поддерживает получение истории файлов")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Git-сервис не поддерживает получение истории файлов"))
+		return
+	}
+
+	// Парсим body запроса
+	var historyData historyRequest
+	err := json.NewDecoder(req.Body).Decode(&historyData)
+	if err != nil || historyData.VulnerabilityId == "" {
+		fmt.Println("Ошибка при декодировании:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	fmt.Println("Репозиторий:", historyData.User+"/"+historyData.Repo)
+	fmt.Println("Уязвимость:", historyData.VulnerabilityId)
+	fmt.Println()
+
+	// Получаем историю lock-файлов
+	histories, err := gitParser.GetLockfileHistory(req.Context(), gitService, historyData.UserInfo)
+	if err != nil {
+		fmt.Println("Ошибка при получении истории файлов:", err)
+		writeFetchError(w, err)
+		return
+	}
+
+	// Сканируем все состояния файлов и ищем периоды уязвимости
+	history, err := osvscanner.DoHistoryScan(histories, historyData.VulnerabilityId)
+	if err != nil {
+		fmt.Println("Ошибка при поиске уязвимостей:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Найдено периодов уязвимости:", len(history.Windows))
+	fmt.Println("==================================")
+
+	// Возвращаем результат
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(history)
+}
+
+// @Summary			Сканирование загруженного архива (zip или tar.gz) на наличие уязвимостей в lock-файлах
+// @Accept			mpfd
+// @Produce			json
+// @Param			repoId			query		int							true	"Id репозитория в БД"
+// @Param			archive			formData	file						true	"Архив с исходным кодом (.zip, .tar.gz, .tgz)"
+// @Param			Include			formData	[]string					false	"Glob-шаблоны путей lock-файлов, которые нужно сканировать (можно передать и в query)"
+// @Param			Exclude			formData	[]string					false	"Glob-шаблоны путей, которые нужно пропустить (можно передать и в query)"
+// @Param			MaxDepth		formData	int							false	"Максимальная глубина вложенности папок (можно передать и в query)"
+// @Success			200				object		severityCounts				"ok"
+// @Failure			400
+// @Failure			413
+// @Failure			500
+// @Router			/upload [post]
+func uploadArchive(w http.ResponseWriter, req *http.Request) {
+	fmt.Println("==================================")
+
+	repoId, err := strconv.Atoi(req.URL.Query().Get("repoId"))
+	if err != nil {
+		fmt.Println("Некорректный Id репозитория:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Архив читается потоком, поэтому ограничиваем размер тела запроса целиком
+	req.Body = http.MaxBytesReader(w, req.Body, maxUploadSize)
+
+	reader, err := req.MultipartReader()
+	if err != nil {
+		fmt.Println("Ошибка при чтении формы:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Ищем в форме файл архива. Поля фильтрации путей должны идти в форме до архива,
+	// так как архив читается потоком, они также принимаются в query.
+	filters := req.URL.Query()
+	var archive *multipart.Part
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println("Ошибка при чтении формы:", err)
+			w.WriteHeader(uploadErrorStatus(err))
+			return
+		}
+		if part.FormName() == "archive" {
+			archive = part
+			break
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxFormValueSize))
+		if err != nil {
+			fmt.Println("Ошибка при чтении формы:", err)
+			w.WriteHeader(uploadErrorStatus(err))
+			return
+		}
+		filters.Add(part.FormName(), string(value))
+	}
+
+	if archive == nil {
+		fmt.Println("В запросе отсутствует архив")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("В запросе отсутствует архив"))
+		return
+	}
+
+	userData, err := archiveUserInfo(filters)
+	if err != nil {
+		fmt.Println("Некорректные параметры фильтрации:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	archiveName := filepath.Base(archive.FileName())
+	fmt.Println("Архив:", archiveName)
+	fmt.Println()
+
+	client := database.PClient.Client
+	ctx := context.Background()
+
+	// Получаем интересующие нас файлы
+	files, err := gitParser.ArchiveGetFiles(archiveName, archive, userData)
+	if err != nil {
+		fmt.Println("Ошибка при распаковке архива:", err)
+		w.WriteHeader(uploadErrorStatus(err))
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	// Помечаем репозиторий, что он сканируется
+	client.Repos.FindMany(
+		db.Repos.ID.Equals(repoId),
+	).Update(
+		db.Repos.Status.Set(db.RepoStatusScanning),
+	).Exec(ctx)
+
+	// Сканируем файлы и сохраняем результат
+	counts, err := scanFiles(files, repoId, db.Scans.Origin.Set(archiveName))
+	if err != nil {
+		fmt.Println(err)
+		resetRepoStatus(repoId)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	fmt.Println()
 	fmt.Println("Успех!")
 	fmt.Println("==================================")
@@ -315,6 +717,214 @@ func parseRepo(w http.ResponseWriter, req *http.Request) {
 	json.NewEncoder(w).Encode(counts)
 }
 
+// Параметры фильтрации путей архива из формы или query, как поля Include, Exclude и MaxDepth у репозиториев
+func archiveUserInfo(values url.Values) (gitParser.UserInfo, error) {
+	userData := gitParser.UserInfo{
+		Include: values["Include"],
+		Exclude: values["Exclude"],
+	}
+
+	if maxDepth := values.Get("MaxDepth"); maxDepth != "" {
+		depth, err := strconv.Atoi(maxDepth)
+		if err != nil || depth < 0 {
+			return gitParser.UserInfo{}, fmt.Errorf("некорректная глубина вложенности папок: %s", maxDepth)
+		}
+		userData.MaxDepth = &depth
+	}
+
+	return userData, nil
+}
+
+// Код ответа для ошибки при чтении архива
+func uploadErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) ||
+		errors.Is(err, gitParser.ErrArchiveFileTooLarge) ||
+		errors.Is(err, gitParser.ErrArchiveTooLarge) ||
+		errors.Is(err, gitParser.ErrArchiveTooManyEntries) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
+
+// Кол-во одновременных сканирований репозиториев организации по умолчанию
+const defaultOrgScanConcurrency = 4
+
+// Запрос на сканирование всех репозиториев организации или пользователя из поля User
+type orgScanRequest struct {
+	gitParser.UserInfo
+	OwnerId      int  // Id пользователя в БД, которому будут принадлежать репозитории
+	SkipArchived bool // Пропускать архивные репозитории
+	SkipForks    bool // Пропускать форки
+}
+
+// Репозиторий, поставленный в очередь на сканирование
+type queuedRepo struct {
+	RepoId int    // Id репозитория в БД
+	Name   string // Наименование репозитория
+}
+
+// Время, которое при остановке воркера даётся текущим запросам и фоновым сканированиям
+const shutdownTimeout = time.Minute
+
+// Контекст фоновых сканирований организаций. Отменяется при остановке воркера,
+// после чего незавершённые репозитории возвращаются в статус NotScanned.
+var backgroundCtx, stopBackground = context.WithCancel(context.Background())
+
+// Запущенные фоновые сканирования организаций
+var backgroundScans sync.WaitGroup
+
+// Получить ограничение на кол-во одновременных сканирований репозиториев организации.
+// Задаётся переменной окружения ORG_SCAN_CONCURRENCY.
+func orgScanConcurrency() int {
+	concurrency, err := strconv.Atoi(os.Getenv("ORG_SCAN_CONCURRENCY"))
+	if err != nil || concurrency < 1 {
+		return defaultOrgScanConcurrency
+	}
+
+	return concurrency
+}
+
+// @Summary			Сканирование всех репозиториев организации или пользователя
+// @Description		Репозитории создаются или обновляются в БД и сканируются в фоне, в ответе возвращается список поставленных в очередь репозиториев.
+// @Accept			json
+// @Produce			json
+// @Param			service			query		string						true	"Наименование сервиса" Enums(github)
+// @Param			org_info		body		orgScanRequest				true	"Организация или пользователь и параметры сканирования"
+// @Success			202				array		queuedRepo					"accepted"
+// @Failure			400				"Сервис не поддерживает получение списка репозиториев или не указан OwnerId"
+// @Failure			500				"Не удалось получить список репозиториев или сохранить ни одного из них"
+// @Failure			503				"Исчерпан лимит запросов к API git-сервиса, повторите после Retry-After"
+// @Router			/org [post]
+func scanOrg(w http.ResponseWriter, req *http.Request) {
+	fmt.Println("==================================")
+
+	// Получаем название сервиса
+	gitService := req.URL.Query().Get("service")
+
+	// Валидация допустимости сервиса
+	if !gitParser.CanListRepositories(gitService) {
+		fmt.Println("Git-сервис не поддерживает получение списка репозиториев")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Git-сервис не поддерживает получение списка репозиториев"))
+		return
+	}
+
+	// Парсим body запроса
+	var orgData orgScanRequest
+	err := json.NewDecoder(req.Body).Decode(&orgData)
+	if err != nil || orgData.User == "" {
+		fmt.Println("Ошибка при декодировании:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Без владельца записи о репозиториях создать нельзя
+	if orgData.OwnerId == 0 {
+		fmt.Println("Не указан владелец репозиториев")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Не указан OwnerId"))
+		return
+	}
+
+	fmt.Println("Организация:", orgData.User)
+
+	repos, err := gitParser.ListRepositories(req.Context(), gitService, orgData.UserInfo, gitParser.ListReposOptions{
+		SkipArchived: orgData.SkipArchived,
+		SkipForks:    orgData.SkipForks,
+	})
+	if err != nil {
+		fmt.Println("Ошибка при получении списка репозиториев:", err)
+		writeFetchError(w, err)
+		return
+	}
+
+	// Создаём или обновляем записи о репозиториях
+	client := database.PClient.Client
+	queued := []queuedRepo{}
+	for _, repo := range repos {
+		// Id в git-сервисе уникален только в пределах инстанса, а запись принадлежит одному пользователю,
+		// поэтому репозиторий ищется по владельцу, адресу инстанса и Id вместе
+		dbRepo, err := client.Repos.UpsertOne(
+			db.Repos.OwnerIDHostRemoteID(
+				db.Repos.OwnerID.Equals(orgData.OwnerId),
+				db.Repos.Host.Equals(repo.Host),
+				db.Repos.RemoteID.Equals(db.BigInt(repo.Id)),
+			),
+		).Create(
+			db.Repos.Name.Set(repo.Name),
+			db.Repos.User.Link(db.Users.ID.Equals(orgData.OwnerId)),
+			db.Repos.Status.Set(db.RepoStatusQueued),
+			db.Repos.Host.Set(repo.Host),
+			db.Repos.RemoteID.Set(db.BigInt(repo.Id)),
+		).Update(
+			db.Repos.Name.Set(repo.Name),
+			db.Repos.Status.Set(db.RepoStatusQueued),
+		).Exec(req.Context())
+
+		if err != nil {
+			fmt.Println("Ошибка при создании/обновлении репозитория", repo.Name+":", err)
+			continue
+		}
+
+		queued = append(queued, queuedRepo{RepoId: dbRepo.ID, Name: repo.Name})
+	}
+
+	// Если не удалось сохранить ни одного репозитория, сканировать нечего и это ошибка, а не пустая очередь
+	if len(repos) > 0 && len(queued) == 0 {
+		fmt.Println("Не удалось сохранить ни одного репозитория")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Не удалось сохранить ни одного репозитория"))
+		return
+	}
+
+	fmt.Println("Репозиториев в очереди:", len(queued))
+	backgroundScans.Add(1)
+	go func() {
+		defer backgroundScans.Done()
+		scanQueuedRepos(backgroundCtx, gitService, orgData.UserInfo, queued)
+	}()
+
+	// Возвращаем список поставленных в очередь репозиториев
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(queued)
+}
+
+// Просканировать репозитории организации в фоне с ограничением на кол-во одновременных сканирований.
+// Если воркер останавливается, репозитории, до которых не дошла очередь, возвращаются в статус NotScanned.
+func scanQueuedRepos(ctx context.Context, gitService string, orgData gitParser.UserInfo, queued []queuedRepo) {
+	var group errgroup.Group
+	group.SetLimit(orgScanConcurrency())
+
+	for _, repo := range queued {
+		group.Go(func() error {
+			if ctx.Err() != nil {
+				resetRepoStatus(repo.RepoId)
+				return nil
+			}
+
+			userData := orgData
+			userData.Repo = repo.Name
+			userData.RepoId = repo.RepoId
+
+			fmt.Println("Репозиторий:", userData.User+"/"+userData.Repo)
+			counts, err := scanRepository(ctx, gitService, userData)
+			if err != nil {
+				fmt.Println("Ошибка при сканировании", userData.User+"/"+userData.Repo+":", err)
+				return nil
+			}
+
+			fmt.Printf("Репозиторий %s/%s просканирован: %+v\n", userData.User, userData.Repo, counts)
+			return nil
+		})
+	}
+
+	group.Wait()
+	fmt.Println("Сканирование организации", orgData.User, "завершено")
+}
+
 // @title			WebScan Worker API
 // @version			1.0
 // @description		Этот сервис ищет lock-файлы в git-репозитории и возвращает список уязвимостей из базы данных osv.dev.
@@ -358,6 +968,53 @@ func main() {
 	// Регистрируем роут до функции сканирования репозитория на наличие уязвимостей
 	r.Post("/parse", parseRepo)
 
+	// Регистрируем роут до функции сканирования изменений между двумя ref
+	r.Post("/diff", diffRepo)
+
+	// Регистрируем роут до функции поиска периодов уязвимости по истории
+	r.Post("/history", historyRepo)
+
+	// Регистрируем роут до функции сканирования загруженного архива
+	r.Post("/upload", uploadArchive)
+
+	// Регистрируем роут до функции сканирования всех репозиториев организации
+	r.Post("/org", scanOrg)
+
+	server := &http.Server{Addr: ":" + os.Getenv("PORT"), Handler: r}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
 	fmt.Println("Процесс запущен! Порт", os.Getenv("PORT"))
-	http.ListenAndServe(":"+os.Getenv("PORT"), r)
+
+	// Ждём сигнала остановки
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	fmt.Println("Остановка процесса...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	server.Shutdown(shutdownCtx)
+	stopBackgroundScans(shutdownCtx)
+}
+
+// Дождаться фоновых сканирований, а если время вышло - прервать их.
+// Прерванные сканирования сбрасывают статус своих репозиториев, поэтому их дожидаемся в любом случае.
+func stopBackgroundScans(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		backgroundScans.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		fmt.Println("Прерываем фоновые сканирования")
+		stopBackground()
+		<-done
+	}
 }
@@ -0,0 +1,109 @@
+package osvscanner
+
+import (
+	"fmt"
+	"sort"
+	"web-scan-worker/src/osvscanner/gitParser"
+	"web-scan-worker/src/osvscanner/models"
+)
+
+// Ключ уязвимости пакета в конкретном источнике.
+// Версия не учитывается, чтобы обновление пакета, не устраняющее уязвимость, не считалось новой уязвимостью.
+type vulnerabilityKey struct {
+	Path      string
+	Ecosystem string
+	Name      string
+	ID        string
+}
+
+// Сгруппировать уязвимости результата по ключу
+func indexVulnerabilities(results models.VulnerabilityResults) map[vulnerabilityKey]models.VulnerabilityChange {
+	index := map[vulnerabilityKey]models.VulnerabilityChange{}
+	for _, vuln := range results.Flatten() {
+		key := vulnerabilityKey{
+			Path:      vuln.Source.Path,
+			Ecosystem: vuln.Package.Ecosystem,
+			Name:      vuln.Package.Name,
+			ID:        vuln.Vulnerability.ID,
+		}
+		index[key] = models.VulnerabilityChange{
+			Source:      vuln.Source,
+			Package:     vuln.Package,
+			ID:          vuln.Vulnerability.ID,
+			Aliases:     vuln.Vulnerability.Aliases,
+			Summary:     vuln.Vulnerability.Summary,
+			MaxSeverity: vuln.GroupInfo.MaxSeverity,
+			Workspaces:  vuln.Workspaces,
+		}
+	}
+
+	return index
+}
+
+// Уязвимости, которые есть в to, но отсутствуют в from
+func subtractVulnerabilities(to, from map[vulnerabilityKey]models.VulnerabilityChange) []models.VulnerabilityChange {
+	changes := []models.VulnerabilityChange{}
+	for key, vuln := range to {
+		if _, ok := from[key]; !ok {
+			changes = append(changes, vuln)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Source.Path != changes[j].Source.Path {
+			return changes[i].Source.Path < changes[j].Source.Path
+		}
+		if changes[i].Package.Name != changes[j].Package.Name {
+			return changes[i].Package.Name < changes[j].Package.Name
+		}
+
+		return changes[i].ID < changes[j].ID
+	})
+
+	return changes
+}
+
+// Получить ошибку первого изменённого файла, у которого она есть
+func changedFilesError(changed gitParser.ChangedFiles) error {
+	for _, files := range [][]gitParser.DepFile{changed.Base, changed.Head} {
+		for _, file := range files {
+			if file.Error != nil {
+				return fmt.Errorf("файл %s: %w", file.Path, file.Error)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Провести OSV-сканирование изменённых файлов и вернуть уязвимости,
+// появившиеся в проверяемом коммите, и уязвимости, исправленные в нём
+func DoDiffScan(changed gitParser.ChangedFiles) (models.VulnerabilityDiff, error) {
+	// Без содержимого файла нельзя понять, какие уязвимости появились или исправлены
+	if err := changedFilesError(changed); err != nil {
+		return models.VulnerabilityDiff{}, err
+	}
+
+	baseResults, err := DoScan(changed.Base)
+	if err != nil {
+		return models.VulnerabilityDiff{}, err
+	}
+
+	headResults, err := DoScan(changed.Head)
+	if err != nil {
+		return models.VulnerabilityDiff{}, err
+	}
+
+	// Файл, который не удалось разобрать, тоже не даёт сравнить состояния
+	if err := changedFilesError(changed); err != nil {
+		return models.VulnerabilityDiff{}, err
+	}
+
+	baseVulns := indexVulnerabilities(baseResults)
+	headVulns := indexVulnerabilities(headResults)
+
+	return models.VulnerabilityDiff{
+		Introduced: subtractVulnerabilities(headVulns, baseVulns),
+		Fixed:      subtractVulnerabilities(baseVulns, headVulns),
+	}, nil
+}
@@ -0,0 +1,190 @@
+package gitParser
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Максимальный размер одного lock-файла внутри архива
+const maxArchiveFileSize = 64 << 20
+
+// Максимальный суммарный размер распакованных файлов архива
+const maxArchiveTotalSize = 1 << 30
+
+// Максимальное кол-во элементов в архиве
+const maxArchiveEntries = 100_000
+
+var ErrUnsupportedArchive = errors.New("неподдерживаемый формат архива, ожидается .zip или .tar.gz")
+var ErrArchiveFileTooLarge = errors.New("файл в архиве превышает допустимый размер")
+var ErrArchiveTooLarge = errors.New("суммарный размер распакованного архива превышает допустимый")
+var ErrArchiveTooManyEntries = errors.New("архив содержит слишком много файлов")
+
+// Учёт распакованного объёма архива, защищает от zip/tar-бомб
+type archiveBudget struct {
+	entries int
+	size    int64
+}
+
+// Учесть очередной элемент архива
+func (b *archiveBudget) addEntry() error {
+	b.entries++
+	if b.entries > maxArchiveEntries {
+		return ErrArchiveTooManyEntries
+	}
+
+	return nil
+}
+
+// Учесть распакованные байты
+func (b *archiveBudget) addSize(size int64) error {
+	b.size += size
+	if size < 0 || b.size > maxArchiveTotalSize {
+		return ErrArchiveTooLarge
+	}
+
+	return nil
+}
+
+// Получить безопасный путь до файла внутри архива.
+// Абсолютные пути и выход за пределы архива через ".." отбрасываются.
+func archiveEntryPath(name string) (string, bool) {
+	name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || name == "." {
+		return "", false
+	}
+
+	return name, true
+}
+
+// Прочитать файл из архива с ограничением по размеру
+func readArchiveFile(entryPath string, r io.Reader, budget *archiveBudget) (DepFile, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxArchiveFileSize+1))
+	if err != nil {
+		return DepFile{}, err
+	}
+	if len(content) > maxArchiveFileSize {
+		return DepFile{}, fmt.Errorf("%w: %s", ErrArchiveFileTooLarge, entryPath)
+	}
+	if err := budget.addSize(int64(len(content))); err != nil {
+		return DepFile{}, err
+	}
+
+	return DepFile{
+		Name:    path.Base(entryPath),
+		Path:    entryPath,
+		Content: string(content),
+	}, nil
+}
+
+// Получить файлы зависимостей из tar.gz архива, читая его потоком
+func tarGzGetFiles(r io.Reader, filter pathFilter) ([]DepFile, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+
+	var budget archiveBudget
+	files := make([]DepFile, 0)
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := budget.addEntry(); err != nil {
+			return nil, err
+		}
+
+		entryPath, ok := archiveEntryPath(header.Name)
+		if !ok || header.Typeflag != tar.TypeReg || !slices.Contains(allowedFiles, path.Base(entryPath)) || !filter.allowFile(entryPath) {
+			// Пропущенные файлы всё равно распаковываются при переходе к следующему элементу
+			if err := budget.addSize(header.Size); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		file, err := readArchiveFile(entryPath, tarReader, &budget)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// Получить файлы зависимостей из zip архива.
+// Zip требует произвольного доступа, поэтому архив сперва сохраняется во временный файл.
+func zipGetFiles(r io.Reader, filter pathFilter) ([]DepFile, error) {
+	tmp, err := os.CreateTemp("", "web-scan-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return nil, err
+	}
+
+	zipReader, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(zipReader.File) > maxArchiveEntries {
+		return nil, ErrArchiveTooManyEntries
+	}
+
+	var budget archiveBudget
+	files := make([]DepFile, 0)
+	for _, entry := range zipReader.File {
+		entryPath, ok := archiveEntryPath(entry.Name)
+		if !ok || !entry.Mode().IsRegular() || !slices.Contains(allowedFiles, path.Base(entryPath)) || !filter.allowFile(entryPath) {
+			continue
+		}
+
+		entryReader, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		file, err := readArchiveFile(entryPath, entryReader, &budget)
+		entryReader.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// Получить файлы зависимостей из архива. Формат определяется по имени архива.
+// Правила отбора путей берутся из data, как и при обходе репозитория.
+func ArchiveGetFiles(name string, r io.Reader, data UserInfo) ([]DepFile, error) {
+	name = strings.ToLower(name)
+	filter := newPathFilter(data)
+
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return zipGetFiles(r, filter)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return tarGzGetFiles(r, filter)
+	}
+
+	return nil, ErrUnsupportedArchive
+}
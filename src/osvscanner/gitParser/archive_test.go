@@ -0,0 +1,97 @@
+package gitParser
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+)
+
+// Собрать tar.gz из заголовков. Содержимое пишется только для файлов с content.
+func buildTarGz(t *testing.T, write func(tw *tar.Writer)) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	write(tw)
+	tw.Flush()
+	gw.Close()
+
+	return &buf
+}
+
+func TestArchiveGetFiles(t *testing.T) {
+	tarGz := buildTarGz(t, func(tw *tar.Writer) {
+		for name, content := range map[string]string{
+			"repo/package-lock.json":    "{}",
+			"../evil/yarn.lock":         "x",
+			"repo/src/requirements.txt": "flask==2.0.0\n",
+			"repo/README.md":            "readme",
+		} {
+			tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+			tw.Write([]byte(content))
+		}
+	})
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for name, content := range map[string]string{"package-lock.json": "{}", "/etc/yarn.lock": "x"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	tests := []struct {
+		name    string
+		archive string
+		data    *bytes.Buffer
+		want    []string
+	}{
+		{"tar.gz", "repo.tar.gz", tarGz, []string{"repo/package-lock.json", "repo/src/requirements.txt"}},
+		{"zip", "repo.zip", &zipBuf, []string{"package-lock.json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := ArchiveGetFiles(tt.archive, tt.data, UserInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]bool{}
+			for _, file := range files {
+				got[file.Path] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("получены файлы %v, ожидались %v", got, tt.want)
+			}
+			for _, want := range tt.want {
+				if !got[want] {
+					t.Errorf("нет файла %s среди %v", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestArchiveLimits(t *testing.T) {
+	// Заголовок обещает файл больше допустимого суммарного размера, сами данные не нужны
+	huge := buildTarGz(t, func(tw *tar.Writer) {
+		tw.WriteHeader(&tar.Header{Name: "big.bin", Mode: 0o644, Size: maxArchiveTotalSize + 1, Typeflag: tar.TypeReg})
+	})
+	if _, err := ArchiveGetFiles("bomb.tar.gz", huge, UserInfo{}); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Errorf("ожидалась ошибка %v, получена %v", ErrArchiveTooLarge, err)
+	}
+
+	many := buildTarGz(t, func(tw *tar.Writer) {
+		for i := 0; i <= maxArchiveEntries; i++ {
+			tw.WriteHeader(&tar.Header{Name: "f", Mode: 0o644, Typeflag: tar.TypeReg})
+		}
+	})
+	if _, err := ArchiveGetFiles("many.tar.gz", many, UserInfo{}); !errors.Is(err, ErrArchiveTooManyEntries) {
+		t.Errorf("ожидалась ошибка %v, получена %v", ErrArchiveTooManyEntries, err)
+	}
+}
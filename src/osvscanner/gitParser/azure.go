@@ -0,0 +1,184 @@
+package gitParser
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"web-scan-worker/src/internal/cachedregexp"
+
+	"github.com/google/go-github/v62/github"
+)
+
+// Адрес Azure DevOps по умолчанию
+const azureDefaultURL = "https://dev.azure.com"
+
+// Версия REST API Azure DevOps
+const azureAPIVersion = "7.0"
+
+// Элемент репозитория в Azure DevOps
+type azureItem struct {
+	Path     string `json:"path"`
+	IsFolder bool   `json:"isFolder"`
+}
+
+// Получить адрес репозитория в API Azure DevOps.
+// Владельцем выступает организация, а если проект не указан, он совпадает с именем репозитория.
+func azureRepoURL(data UserInfo) string {
+	baseURL := data.Url
+	if baseURL == "" {
+		baseURL = azureDefaultURL
+	}
+
+	project := data.Project
+	if project == "" {
+		project = data.Repo
+	}
+
+	return strings.TrimSuffix(baseURL, "/") + "/" + url.PathEscape(data.User) + "/" + url.PathEscape(project) +
+		"/_apis/git/repositories/" + url.PathEscape(data.Repo)
+}
+
+// Заголовки авторизации. PAT передаётся через Basic-авторизацию с пустым логином.
+func azureHeaders(data UserInfo) map[string]string {
+	if data.Token == "" {
+		return nil
+	}
+
+	credentials := base64.StdEncoding.EncodeToString([]byte(":" + data.Token))
+	return map[string]string{"Authorization": "Basic " + credentials}
+}
+
+// Тип версии для ref: коммит, если это SHA, иначе ветка
+func azureVersionType(ref string) string {
+	if cachedregexp.MustCompile(`^[0-9a-fA-F]{40}$`).MatchString(ref) {
+		return "commit"
+	}
+
+	return "branch"
+}
+
+// Параметры запроса к Items API с учётом ref
+func azureItemsQuery(data UserInfo) url.Values {
+	query := url.Values{}
+	query.Set("api-version", azureAPIVersion)
+
+	if data.Ref != "" {
+		query.Set("versionDescriptor.version", data.Ref)
+		query.Set("versionDescriptor.versionType", azureVersionType(data.Ref))
+	}
+
+	return query
+}
+
+// Получить SHA коммита, на который указывает ref
+func AzureResolveRef(ctx context.Context, data UserInfo) (RefInfo, error) {
+	ref := data.Ref
+	if ref == "" {
+		var repo struct {
+			DefaultBranch string `json:"defaultBranch"`
+		}
+		if _, err := restGetJSON(ctx, azureRepoURL(data)+"?api-version="+azureAPIVersion, azureHeaders(data), &repo); err != nil {
+			return RefInfo{}, err
+		}
+		ref = strings.TrimPrefix(repo.DefaultBranch, "refs/heads/")
+	}
+
+	// По имени нельзя отличить ветку от тега, поэтому если ветка не найдена, ищем тег
+	versionTypes := []string{azureVersionType(ref)}
+	if versionTypes[0] == "branch" {
+		versionTypes = append(versionTypes, "tag")
+	}
+
+	var err error
+	for _, versionType := range versionTypes {
+		var commit string
+		commit, err = azureRefCommit(ctx, data, ref, versionType)
+		if err == nil {
+			return RefInfo{Ref: ref, Commit: commit}, nil
+		}
+	}
+
+	return RefInfo{}, err
+}
+
+// Получить коммит, на который указывает ref заданного типа (branch, tag или commit)
+func azureRefCommit(ctx context.Context, data UserInfo, ref, versionType string) (string, error) {
+	query := url.Values{}
+	query.Set("api-version", azureAPIVersion)
+	query.Set("searchCriteria.itemVersion.version", ref)
+	query.Set("searchCriteria.itemVersion.versionType", versionType)
+	query.Set("searchCriteria.$top", "1")
+
+	var commits struct {
+		Value []struct {
+			CommitID string `json:"commitId"`
+		} `json:"value"`
+	}
+	if _, err := restGetJSON(ctx, azureRepoURL(data)+"/commits?"+query.Encode(), azureHeaders(data), &commits); err != nil {
+		return "", err
+	}
+	if len(commits.Value) == 0 {
+		return "", fmt.Errorf("не найден коммит для %s", ref)
+	}
+
+	return commits.Value[0].CommitID, nil
+}
+
+// Получить содержимое папки.
+// Items API умеет отдавать всё дерево за один запрос, поэтому возвращаем
+// файлы всех вложенных папок сразу, а список папок оставляем пустым.
+func AzureGetContents(ctx context.Context, dirPath string, data UserInfo) (Directory, error) {
+	fmt.Println("Пробуем получить содержимое из \"" + dirPath + "\"")
+
+	query := azureItemsQuery(data)
+	query.Set("scopePath", "/"+strings.Trim(dirPath, "/"))
+	query.Set("recursionLevel", "Full")
+
+	var items struct {
+		Value []azureItem `json:"value"`
+	}
+	if _, err := restGetJSON(ctx, azureRepoURL(data)+"/items?"+query.Encode(), azureHeaders(data), &items); err != nil {
+		return Directory{}, err
+	}
+
+	var files []github.RepositoryContent
+
+	for _, item := range items.Value {
+		if item.IsFolder {
+			continue
+		}
+
+		itemPath := strings.TrimPrefix(item.Path, "/")
+		files = append(files, github.RepositoryContent{
+			Type: github.String("file"),
+			Name: github.String(path.Base(itemPath)),
+			Path: github.String(itemPath),
+		})
+	}
+
+	return Directory{files: files}, nil
+}
+
+// Получить содержимое файла.
+func AzureDownload(ctx context.Context, file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error) {
+	fmt.Println("Пробуем скачать ", file.GetPath())
+
+	query := azureItemsQuery(data)
+	query.Set("path", "/"+file.GetPath())
+	query.Set("$format", "octetStream")
+
+	content, _, err := restGet(ctx, azureRepoURL(data)+"/items?"+query.Encode(), azureHeaders(data))
+	if err != nil {
+		return github.RepositoryContent{}, err
+	}
+
+	return github.RepositoryContent{
+		Type:    github.String("file"),
+		Name:    github.String(file.GetName()),
+		Path:    github.String(file.GetPath()),
+		Content: github.String(string(content)),
+	}, nil
+}
@@ -0,0 +1,54 @@
+package gitParser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAzureResolveRef(t *testing.T) {
+	refs := map[string]string{
+		"branch:main": "1111111111111111111111111111111111111111",
+		"tag:v1.0.0":  "2222222222222222222222222222222222222222",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/org/project/_apis/git/repositories/repo/commits" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		query := r.URL.Query()
+		commit, ok := refs[query.Get("searchCriteria.itemVersion.versionType")+":"+query.Get("searchCriteria.itemVersion.version")]
+		if !ok {
+			// Azure DevOps отвечает 404, если ref указанного типа не найден
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"count":1,"value":[{"commitId":"` + commit + `"}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"main", refs["branch:main"], false},
+		{"v1.0.0", refs["tag:v1.0.0"], false},
+		{"missing", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			ref, err := ResolveRef(context.Background(), "azure", UserInfo{Url: server.URL, User: "org", Project: "project", Repo: "repo", Ref: tt.ref})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка %v", err)
+			}
+			if ref.Commit != tt.want {
+				t.Errorf("получен коммит %q, ожидался %q", ref.Commit, tt.want)
+			}
+		})
+	}
+}
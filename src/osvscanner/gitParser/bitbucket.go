@@ -0,0 +1,318 @@
+package gitParser
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v62/github"
+)
+
+// Адрес API Bitbucket Cloud по умолчанию
+const bitbucketCloudDefaultURL = "https://api.bitbucket.org"
+
+// Максимальное кол-во элементов на страницу
+const bitbucketPerPage = 100
+
+var ErrBitbucketServerURLMissing = errors.New("не указан адрес инстанса Bitbucket Server")
+
+// Заголовки авторизации.
+// Если указан логин, то токен считается app password и передаётся через Basic-авторизацию,
+// иначе он передаётся как access token.
+func bitbucketHeaders(data UserInfo) map[string]string {
+	if data.Token == "" {
+		return nil
+	}
+
+	if data.Login != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(data.Login + ":" + data.Token))
+		return map[string]string{"Authorization": "Basic " + credentials}
+	}
+
+	return map[string]string{"Authorization": "Bearer " + data.Token}
+}
+
+// Страница содержимого папки в Bitbucket Cloud
+type bitbucketCloudSrcPage struct {
+	Values []struct {
+		Type string `json:"type"`
+		Path string `json:"path"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+// Получить адрес репозитория в API Bitbucket Cloud
+func bitbucketCloudRepoURL(data UserInfo) string {
+	baseURL := data.Url
+	if baseURL == "" {
+		baseURL = bitbucketCloudDefaultURL
+	}
+
+	return strings.TrimSuffix(baseURL, "/") + "/2.0/repositories/" + url.PathEscape(data.User) + "/" + url.PathEscape(data.Repo)
+}
+
+// Получить ref для запроса. Bitbucket Cloud требует его в пути,
+// поэтому если он не указан, берём основную ветку репозитория.
+func bitbucketCloudRef(ctx context.Context, data UserInfo) (string, error) {
+	if data.Ref != "" {
+		return data.Ref, nil
+	}
+
+	var repo struct {
+		MainBranch struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+	}
+	if _, err := restGetJSON(ctx, bitbucketCloudRepoURL(data), bitbucketHeaders(data), &repo); err != nil {
+		return "", err
+	}
+
+	return repo.MainBranch.Name, nil
+}
+
+// Получить SHA коммита, на который указывает ref
+func BitbucketCloudResolveRef(ctx context.Context, data UserInfo) (RefInfo, error) {
+	ref, err := bitbucketCloudRef(ctx, data)
+	if err != nil {
+		return RefInfo{}, err
+	}
+
+	var commit struct {
+		Hash string `json:"hash"`
+	}
+	if _, err := restGetJSON(ctx, bitbucketCloudRepoURL(data)+"/commit/"+url.PathEscape(ref), bitbucketHeaders(data), &commit); err != nil {
+		return RefInfo{}, err
+	}
+
+	return RefInfo{Ref: ref, Commit: commit.Hash}, nil
+}
+
+// Получить содержимое папки
+func BitbucketCloudGetContents(ctx context.Context, dirPath string, data UserInfo) (Directory, error) {
+	fmt.Println("Пробуем получить содержимое из \"" + dirPath + "\"")
+
+	ref, err := bitbucketCloudRef(ctx, data)
+	if err != nil {
+		return Directory{}, err
+	}
+
+	rawURL := bitbucketCloudRepoURL(data) + "/src/" + url.PathEscape(ref) + "/"
+	if escaped := escapePath(dirPath); escaped != "" {
+		rawURL += escaped + "/"
+	}
+	rawURL += "?pagelen=" + strconv.Itoa(bitbucketPerPage)
+
+	var files []github.RepositoryContent
+	var folders []github.RepositoryContent
+
+	// Ссылка на следующую страницу приходит в теле ответа
+	for rawURL != "" {
+		var page bitbucketCloudSrcPage
+		if _, err := restGetJSON(ctx, rawURL, bitbucketHeaders(data), &page); err != nil {
+			return Directory{}, err
+		}
+
+		for _, item := range page.Values {
+			switch item.Type {
+			case "commit_file":
+				files = append(files, github.RepositoryContent{
+					Type: github.String("file"),
+					Name: github.String(path.Base(item.Path)),
+					Path: github.String(item.Path),
+				})
+			case "commit_directory":
+				folders = append(folders, github.RepositoryContent{
+					Type: github.String("dir"),
+					Name: github.String(path.Base(item.Path)),
+					Path: github.String(item.Path),
+				})
+			}
+		}
+
+		if page.Next == rawURL {
+			return Directory{}, fmt.Errorf("Bitbucket Cloud вернул ссылку на ту же страницу для папки %s", dirPath)
+		}
+		rawURL = page.Next
+	}
+
+	return Directory{folders: folders, files: files}, nil
+}
+
+// Получить содержимое файла.
+func BitbucketCloudDownload(ctx context.Context, file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error) {
+	fmt.Println("Пробуем скачать ", file.GetPath())
+
+	ref, err := bitbucketCloudRef(ctx, data)
+	if err != nil {
+		return github.RepositoryContent{}, err
+	}
+
+	rawURL := bitbucketCloudRepoURL(data) + "/src/" + url.PathEscape(ref) + "/" + escapePath(file.GetPath())
+	content, _, err := restGet(ctx, rawURL, bitbucketHeaders(data))
+	if err != nil {
+		return github.RepositoryContent{}, err
+	}
+
+	return github.RepositoryContent{
+		Type:    github.String("file"),
+		Name:    github.String(file.GetName()),
+		Path:    github.String(file.GetPath()),
+		Content: github.String(string(content)),
+	}, nil
+}
+
+// Страница содержимого папки в Bitbucket Server
+type bitbucketServerBrowsePage struct {
+	Children struct {
+		Values []struct {
+			Path struct {
+				ToString string `json:"toString"`
+			} `json:"path"`
+			Type string `json:"type"`
+		} `json:"values"`
+		IsLastPage    bool `json:"isLastPage"`
+		NextPageStart *int `json:"nextPageStart"`
+	} `json:"children"`
+}
+
+// Получить адрес репозитория в API Bitbucket Server.
+// В качестве владельца выступает ключ проекта.
+func bitbucketServerRepoURL(data UserInfo) (string, error) {
+	baseURL := data.Url
+	if baseURL == "" {
+		baseURL = os.Getenv("BITBUCKET_SERVER_URL")
+	}
+	if baseURL == "" {
+		return "", ErrBitbucketServerURLMissing
+	}
+
+	return strings.TrimSuffix(baseURL, "/") + "/rest/api/1.0/projects/" + url.PathEscape(data.User) + "/repos/" + url.PathEscape(data.Repo), nil
+}
+
+// Получить SHA коммита, на который указывает ref
+func BitbucketServerResolveRef(ctx context.Context, data UserInfo) (RefInfo, error) {
+	repoURL, err := bitbucketServerRepoURL(data)
+	if err != nil {
+		return RefInfo{}, err
+	}
+
+	ref := data.Ref
+	if ref == "" {
+		var branch struct {
+			DisplayID string `json:"displayId"`
+		}
+		if _, err := restGetJSON(ctx, repoURL+"/branches/default", bitbucketHeaders(data), &branch); err != nil {
+			return RefInfo{}, err
+		}
+		ref = branch.DisplayID
+	}
+
+	var commit struct {
+		ID string `json:"id"`
+	}
+	if _, err := restGetJSON(ctx, repoURL+"/commits/"+url.PathEscape(ref), bitbucketHeaders(data), &commit); err != nil {
+		return RefInfo{}, err
+	}
+
+	return RefInfo{Ref: ref, Commit: commit.ID}, nil
+}
+
+// Получить содержимое папки
+func BitbucketServerGetContents(ctx context.Context, dirPath string, data UserInfo) (Directory, error) {
+	fmt.Println("Пробуем получить содержимое из \"" + dirPath + "\"")
+
+	repoURL, err := bitbucketServerRepoURL(data)
+	if err != nil {
+		return Directory{}, err
+	}
+
+	browseURL := repoURL + "/browse"
+	prefix := ""
+	if escaped := escapePath(dirPath); escaped != "" {
+		browseURL += "/" + escaped
+		prefix = strings.Trim(dirPath, "/") + "/"
+	}
+
+	var files []github.RepositoryContent
+	var folders []github.RepositoryContent
+
+	start := 0
+	for {
+		query := url.Values{}
+		query.Set("start", strconv.Itoa(start))
+		query.Set("limit", strconv.Itoa(bitbucketPerPage))
+		if data.Ref != "" {
+			query.Set("at", data.Ref)
+		}
+
+		var page bitbucketServerBrowsePage
+		if _, err := restGetJSON(ctx, browseURL+"?"+query.Encode(), bitbucketHeaders(data), &page); err != nil {
+			return Directory{}, err
+		}
+
+		// Пути детей указываются относительно текущей папки
+		for _, item := range page.Children.Values {
+			itemPath := prefix + item.Path.ToString
+			switch item.Type {
+			case "FILE":
+				files = append(files, github.RepositoryContent{
+					Type: github.String("file"),
+					Name: github.String(path.Base(itemPath)),
+					Path: github.String(itemPath),
+				})
+			case "DIRECTORY":
+				folders = append(folders, github.RepositoryContent{
+					Type: github.String("dir"),
+					Name: github.String(path.Base(itemPath)),
+					Path: github.String(itemPath),
+				})
+			}
+		}
+
+		if page.Children.IsLastPage {
+			break
+		}
+
+		// Без продвижения по страницам обход бы никогда не закончился
+		next := page.Children.NextPageStart
+		if next == nil || *next <= start {
+			return Directory{}, fmt.Errorf("Bitbucket Server не вернул следующую страницу для папки %s", dirPath)
+		}
+		start = *next
+	}
+
+	return Directory{folders: folders, files: files}, nil
+}
+
+// Получить содержимое файла.
+func BitbucketServerDownload(ctx context.Context, file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error) {
+	fmt.Println("Пробуем скачать ", file.GetPath())
+
+	repoURL, err := bitbucketServerRepoURL(data)
+	if err != nil {
+		return github.RepositoryContent{}, err
+	}
+
+	rawURL := repoURL + "/raw/" + escapePath(file.GetPath())
+	if data.Ref != "" {
+		rawURL += "?at=" + url.QueryEscape(data.Ref)
+	}
+
+	content, _, err := restGet(ctx, rawURL, bitbucketHeaders(data))
+	if err != nil {
+		return github.RepositoryContent{}, err
+	}
+
+	return github.RepositoryContent{
+		Type:    github.String("file"),
+		Name:    github.String(file.GetName()),
+		Path:    github.String(file.GetPath()),
+		Content: github.String(string(content)),
+	}, nil
+}
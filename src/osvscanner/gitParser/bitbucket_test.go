@@ -0,0 +1,96 @@
+package gitParser
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Получить пути файлов и папок содержимого папки в отсортированном порядке
+func directoryPaths(dir Directory) []string {
+	var paths []string
+	for _, folder := range dir.folders {
+		paths = append(paths, folder.GetPath()+"/")
+	}
+	for _, file := range dir.files {
+		paths = append(paths, file.GetPath())
+	}
+	slices.Sort(paths)
+
+	return paths
+}
+
+func TestBitbucketCloudGetContentsPaging(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2.0/repositories/team/repo/src/main/backend/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// Ссылка на вторую страницу приходит в поле next первой
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"values":[{"type":"commit_directory","path":"backend/api"}]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"values": []map[string]string{{"type": "commit_file", "path": "backend/requirements.txt"}},
+			"next":   server.URL + r.URL.Path + "?page=2",
+		})
+	}))
+	defer server.Close()
+
+	dir, err := BitbucketCloudGetContents(context.Background(), "backend", UserInfo{Url: server.URL, User: "team", Repo: "repo", Ref: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := directoryPaths(dir); !slices.Equal(got, []string{"backend/api/", "backend/requirements.txt"}) {
+		t.Errorf("получено содержимое %v", got)
+	}
+}
+
+func TestBitbucketServerGetContentsPaging(t *testing.T) {
+	pages := map[string]string{
+		"0": `{"children":{"values":[{"path":{"toString":"package-lock.json"},"type":"FILE"}],"isLastPage":false,"nextPageStart":1}}`,
+		"1": `{"children":{"values":[{"path":{"toString":"backend"},"type":"DIRECTORY"}],"isLastPage":true}}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PRJ/repos/repo/browse" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		page, ok := pages[r.URL.Query().Get("start")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	data := UserInfo{Url: server.URL, User: "PRJ", Repo: "repo"}
+	dir, err := BitbucketServerGetContents(context.Background(), "/", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := directoryPaths(dir); !slices.Equal(got, []string{"backend/", "package-lock.json"}) {
+		t.Errorf("получено содержимое %v", got)
+	}
+
+	// Страница без продвижения не должна зацикливать обход
+	for _, page := range []string{
+		`{"children":{"values":[],"isLastPage":false}}`,
+		`{"children":{"values":[],"isLastPage":false,"nextPageStart":0}}`,
+	} {
+		pages["0"] = page
+		_, err := BitbucketServerGetContents(context.Background(), "/", data)
+		if err == nil || !strings.Contains(err.Error(), "следующую страницу") {
+			t.Errorf("страница %s: получена ошибка %v", page, err)
+		}
+	}
+}
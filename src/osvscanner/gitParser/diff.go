@@ -0,0 +1,66 @@
+package gitParser
+
+import "context"
+
+// Файлы зависимостей, изменённые между двумя коммитами
+type ChangedFiles struct {
+	Base []DepFile // Версии файлов в базовом коммите
+	Head []DepFile // Версии файлов в проверяемом коммите
+}
+
+type getChangedFilesFunc func(ctx context.Context, data UserInfo, base string, head string) (ChangedFiles, error)
+
+// Сервисы, которые умеют получать список изменённых файлов без загрузки всего дерева
+var gitGetChangedFiles = map[string]getChangedFilesFunc{
+	"github": GitHubGetChangedFiles,
+}
+
+// Получить файлы зависимостей, изменённые между base и head.
+// Если сервис не умеет сравнивать коммиты, получаем файлы обоих коммитов и сравниваем их содержимое.
+func GetChangedFiles(ctx context.Context, service string, user UserInfo, base string, head string) (ChangedFiles, error) {
+	if getChangedFiles := gitGetChangedFiles[service]; getChangedFiles != nil {
+		return getChangedFiles(ctx, user, base, head)
+	}
+
+	return compareRepositoryFiles(ctx, service, user, base, head)
+}
+
+// Получить файлы зависимостей обоих коммитов целиком и оставить те, содержимое которых отличается
+func compareRepositoryFiles(ctx context.Context, service string, user UserInfo, base string, head string) (ChangedFiles, error) {
+	user.Ref = base
+	baseFiles, err := GetFilesFromRepository(ctx, service, user)
+	if err != nil {
+		return ChangedFiles{}, err
+	}
+
+	user.Ref = head
+	headFiles, err := GetFilesFromRepository(ctx, service, user)
+	if err != nil {
+		return ChangedFiles{}, err
+	}
+
+	baseContents := map[string]string{}
+	for _, file := range baseFiles {
+		baseContents[file.Path] = file.Content
+	}
+	headContents := map[string]string{}
+	for _, file := range headFiles {
+		headContents[file.Path] = file.Content
+	}
+
+	// Манифесты не сравниваются, как и при сравнении через API сервиса:
+	// их версии не закреплены, и разница между коммитами зависит от момента сканирования
+	var changed ChangedFiles
+	for _, file := range baseFiles {
+		if content, ok := headContents[file.Path]; !IsManifest(file.Name) && (!ok || content != file.Content) {
+			changed.Base = append(changed.Base, file)
+		}
+	}
+	for _, file := range headFiles {
+		if content, ok := baseContents[file.Path]; !IsManifest(file.Name) && (!ok || content != file.Content) {
+			changed.Head = append(changed.Head, file)
+		}
+	}
+
+	return changed, nil
+}
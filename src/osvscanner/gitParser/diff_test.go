@@ -0,0 +1,59 @@
+package gitParser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompareRepositoryFiles(t *testing.T) {
+	bare := newBareRepo(t, map[string]string{
+		"package-lock.json":        `{"lockfileVersion":3,"packages":{}}`,
+		"backend/requirements.txt": "flask==2.0.0\n",
+		"web/package.json":         `{"dependencies":{"react":"^17.0.0"}}`,
+	})
+	t.Setenv("GIT_ALLOW_FILE_PROTOCOL", "true")
+
+	// Во втором коммите меняются lock-файл и манифест без lock-файла
+	work := filepath.Join(t.TempDir(), "work")
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		output, err := runGit(context.Background(), dir, nil, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(output)
+	}
+	git("", "clone", "--quiet", bare, work)
+	base := git(work, "rev-parse", "HEAD")
+	for name, content := range map[string]string{
+		"backend/requirements.txt": "flask==2.3.0\n",
+		"web/package.json":         `{"dependencies":{"react":"^18.0.0"}}`,
+	} {
+		if err := os.WriteFile(filepath.Join(work, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git(work, "commit", "--quiet", "-am", "update")
+	head := git(work, "rev-parse", "HEAD")
+	git(work, "push", "--quiet", "origin", "main")
+
+	changed, err := GetChangedFiles(context.Background(), "git", UserInfo{Url: "file://" + bare}, base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, files := range map[string][]DepFile{"base": changed.Base, "head": changed.Head} {
+		var got []string
+		for _, file := range files {
+			got = append(got, file.Path)
+		}
+		if !slices.Equal(got, []string{"backend/requirements.txt"}) {
+			t.Errorf("%s: получены изменённые файлы %v", name, got)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package gitParser
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"web-scan-worker/src/osvscanner/models"
 )
@@ -15,14 +16,37 @@ func findParser(pathToLockfile DepFile) (PackageDetailsParser, string) {
 }
 
 var Parsers = map[string]PackageDetailsParser{
-	"package-lock.json": ParseNpmLock,
-	"requirements.txt":  ParseRequirementsTxt,
+	"package-lock.json":   ParseNpmLock,
+	"npm-shrinkwrap.json": ParseNpmLock,
+	"requirements.txt":    ParseRequirementsTxt,
+	"yarn.lock":           ParseYarnLock,
+	"pnpm-lock.yaml":      ParsePnpmLock,
+	"bun.lock":            ParseBunLock,
+	"package.json":        ParseNpmPackageJson,
+}
+
+// Манифесты с диапазонами версий вместо точных версий. Сканируются, только если для них нет lock-файла.
+var manifestFiles = []string{"package.json"}
+
+// Проверка, что файл - манифест, а не lock-файл
+func IsManifest(name string) bool {
+	return slices.Contains(manifestFiles, name)
+}
+
+// Тип источника пакетов: "lockfile" с точными версиями или "manifest" с незакреплёнными версиями
+func SourceType(name string) string {
+	if IsManifest(name) {
+		return "manifest"
+	}
+
+	return "lockfile"
 }
 
 type DepFile struct {
 	Name    string
 	Path    string
 	Content string
+	Error   error // Причина, по которой не удалось получить содержимое файла (такой файл не сканируется)
 }
 
 // Парсинг lock-файла.
@@ -0,0 +1,49 @@
+package gitParser
+
+import (
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"web-scan-worker/src/osvscanner/models"
+)
+
+// Прочитать фикстуру из testdata как файл зависимостей с именем name
+func readFixture(t *testing.T, fixture string, name string) DepFile {
+	t.Helper()
+
+	content, err := os.ReadFile(path.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return DepFile{Name: name, Path: fixture, Content: string(content)}
+}
+
+// Записать пакеты как "name@version" с группами зависимостей через пробел, в отсортированном порядке
+func packageStrings(packages []models.PackageDetails) []string {
+	result := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		str := pkg.Name + "@" + pkg.Version
+		if len(pkg.DepGroups) > 0 {
+			groups := slices.Clone(pkg.DepGroups)
+			slices.Sort(groups)
+			str += " " + strings.Join(groups, ",")
+		}
+		result = append(result, str)
+	}
+	slices.Sort(result)
+
+	return result
+}
+
+// Сравнить найденные пакеты с ожидаемыми
+func expectPackages(t *testing.T, got []models.PackageDetails, want []string) {
+	t.Helper()
+
+	slices.Sort(want)
+	if gotStrings := packageStrings(got); !slices.Equal(gotStrings, want) {
+		t.Errorf("получены пакеты\n%s\nожидались\n%s", strings.Join(gotStrings, "\n"), strings.Join(want, "\n"))
+	}
+}
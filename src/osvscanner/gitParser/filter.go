@@ -0,0 +1,132 @@
+package gitParser
+
+import (
+	"path"
+	"strings"
+)
+
+// Шаблоны путей, которые пропускаются по умолчанию: зависимости, тестовые данные и примеры
+var defaultExclude = []string{
+	"**/node_modules/**",
+	"**/bower_components/**",
+	"**/vendor/**",
+	"**/test/fixtures/**",
+	"**/tests/fixtures/**",
+	"**/__fixtures__/**",
+	"**/testdata/**",
+	"**/example/**",
+	"**/examples/**",
+}
+
+// Правила отбора путей при обходе репозитория
+type pathFilter struct {
+	include  []string
+	exclude  []string
+	maxDepth int    // -1 - без ограничений
+	prefix   string // Путь подмодуля, внутри которого проверяются пути (пустой для основного репозитория)
+}
+
+// Собрать правила из запроса.
+// Если список исключений не передан, используется defaultExclude, пустой список отключает исключения.
+func newPathFilter(data UserInfo) pathFilter {
+	filter := pathFilter{
+		include:  data.Include,
+		exclude:  data.Exclude,
+		maxDepth: -1,
+	}
+	if filter.exclude == nil {
+		filter.exclude = defaultExclude
+	}
+	if data.MaxDepth != nil {
+		filter.maxDepth = *data.MaxDepth
+	}
+
+	return filter
+}
+
+// Правила для путей внутри подмодуля, лежащего по указанному пути
+func (f pathFilter) under(prefix string) pathFilter {
+	f.prefix = path.Join(f.prefix, prefix)
+	return f
+}
+
+// Сопоставить сегменты пути с сегментами шаблона.
+// "**" соответствует любому кол-ву сегментов, в т.ч. нулю, остальные сегменты сравниваются через path.Match.
+func matchSegments(pattern []string, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	matched, err := path.Match(pattern[0], segments[0])
+	if err != nil || !matched {
+		return false
+	}
+
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// Проверить путь на соответствие glob-шаблону
+func matchGlob(pattern string, filePath string) bool {
+	pattern = strings.Trim(pattern, "/")
+	filePath = strings.Trim(filePath, "/")
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(filePath, "/"))
+}
+
+// Проверить путь на соответствие хотя бы одному шаблону
+func matchAny(patterns []string, filePath string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, filePath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Глубина вложенности пути: кол-во папок от корня репозитория
+func pathDepth(filePath string) int {
+	filePath = strings.Trim(filePath, "/")
+	if filePath == "" || filePath == "." {
+		return 0
+	}
+
+	return strings.Count(filePath, "/") + 1
+}
+
+// Нужно ли заходить в папку.
+// Шаблоны включения к папкам не применяются, так как подходящий файл может лежать глубже.
+func (f pathFilter) allowDir(dirPath string) bool {
+	dirPath = path.Join(f.prefix, dirPath)
+	if f.maxDepth >= 0 && pathDepth(dirPath) > f.maxDepth {
+		return false
+	}
+
+	return !matchAny(f.exclude, dirPath)
+}
+
+// Нужно ли скачивать файл
+func (f pathFilter) allowFile(filePath string) bool {
+	filePath = path.Join(f.prefix, filePath)
+	if f.maxDepth >= 0 && pathDepth(path.Dir(strings.Trim(filePath, "/"))) > f.maxDepth {
+		return false
+	}
+	if matchAny(f.exclude, filePath) {
+		return false
+	}
+
+	return len(f.include) == 0 || matchAny(f.include, filePath)
+}
@@ -0,0 +1,74 @@
+package gitParser
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"package-lock.json", "package-lock.json", true},
+		{"package-lock.json", "app/package-lock.json", false},
+		{"**/package-lock.json", "package-lock.json", true},
+		{"**/package-lock.json", "a/b/c/package-lock.json", true},
+		{"apps/**", "apps", true},
+		{"apps/**", "apps/web/yarn.lock", true},
+		{"apps/**/yarn.lock", "apps/yarn.lock", true},
+		{"apps/**/yarn.lock", "apps/web/ui/yarn.lock", true},
+		{"apps/**/yarn.lock", "libs/web/yarn.lock", false},
+		{"apps/*/yarn.lock", "apps/web/ui/yarn.lock", false},
+		{"/apps/*.lock/", "apps/yarn.lock", true},
+		{"**/node_modules/**", "node_modules", true},
+		{"**/node_modules/**", "web/node_modules/pkg", true},
+		{"**/node_modules/**", "web/node_modules_old", false},
+		{"[", "[", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, ожидалось %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPathFilter(t *testing.T) {
+	depth := func(depth int) *int { return &depth }
+
+	tests := []struct {
+		name     string
+		filter   pathFilter
+		path     string
+		wantDir  bool
+		wantFile bool
+	}{
+		{"исключения по умолчанию: node_modules", newPathFilter(UserInfo{}), "web/node_modules", false, false},
+		{"исключения по умолчанию: vendor", newPathFilter(UserInfo{}), "vendor", false, false},
+		{"исключения по умолчанию: фикстуры", newPathFilter(UserInfo{}), "pkg/test/fixtures/package-lock.json", false, false},
+		{"исключения по умолчанию: обычная папка", newPathFilter(UserInfo{}), "backend/package-lock.json", true, true},
+		{"пустой список исключений", newPathFilter(UserInfo{Exclude: []string{}}), "vendor/package-lock.json", true, true},
+		{"свои исключения заменяют стандартные", newPathFilter(UserInfo{Exclude: []string{"legacy/**"}}), "vendor/package-lock.json", true, true},
+		{"свои исключения", newPathFilter(UserInfo{Exclude: []string{"legacy/**"}}), "legacy/package-lock.json", false, false},
+		{"include не ограничивает папки", newPathFilter(UserInfo{Include: []string{"apps/**/yarn.lock"}}), "libs/package-lock.json", true, false},
+		{"include", newPathFilter(UserInfo{Include: []string{"apps/**/yarn.lock"}}), "apps/web/yarn.lock", true, true},
+		{"глубина 0: корень", newPathFilter(UserInfo{MaxDepth: depth(0)}), "package-lock.json", false, true},
+		{"глубина 0: папка", newPathFilter(UserInfo{MaxDepth: depth(0)}), "web/package-lock.json", false, false},
+		{"глубина 1", newPathFilter(UserInfo{MaxDepth: depth(1)}), "web/package-lock.json", false, true},
+		{"глубина 1: вложенная папка", newPathFilter(UserInfo{MaxDepth: depth(1)}), "web/ui/package-lock.json", false, false},
+		{"подмодуль: исключения считаются от основного репозитория", newPathFilter(UserInfo{}).under("vendor/lib"), "package-lock.json", false, false},
+		{"подмодуль: include", newPathFilter(UserInfo{Include: []string{"libs/core/*.lock"}}).under("libs/core"), "yarn.lock", true, true},
+		{"подмодуль: глубина", newPathFilter(UserInfo{MaxDepth: depth(2)}).under("libs/core"), "web/package-lock.json", false, false},
+		{"вложенный подмодуль", newPathFilter(UserInfo{Include: []string{"a/b/*.lock"}}).under("a").under("b"), "yarn.lock", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.allowDir(tt.path); got != tt.wantDir {
+				t.Errorf("allowDir(%q) = %v, ожидалось %v", tt.path, got, tt.wantDir)
+			}
+			if got := tt.filter.allowFile(tt.path); got != tt.wantFile {
+				t.Errorf("allowFile(%q) = %v, ожидалось %v", tt.path, got, tt.wantFile)
+			}
+		})
+	}
+}
@@ -1,29 +1,99 @@
 package gitParser
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"slices"
+	"strconv"
 
 	"github.com/google/go-github/v62/github"
 	"golang.org/x/exp/maps"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 )
 
 type UserInfo struct {
-	Token  string // Access token пользователя в сервисе git
-	User   string // Имя пользователя в сервисе git
-	Repo   string // Наименование репозитория без указания владельца
-	RepoId int    // Id репозитория в БД
+	Token   string // Access token пользователя в сервисе git (для GitHub необязательно, если воркер настроен как GitHub App)
+	User    string // Имя пользователя в сервисе git
+	Repo    string // Наименование репозитория без указания владельца
+	Project string // Проект внутри организации (необязательно, используется в Azure DevOps)
+	RepoId  int    // Id репозитория в БД
+	Login   string // Логин для авторизации по паре логин/пароль (необязательно, например app password в Bitbucket)
+	Url     string // Адрес self-hosted инстанса git-сервиса (необязательно)
+	Path    string // Путь до папки относительно LOCAL_SCAN_ROOT (для сервиса local)
+	Ref     string // Ветка, тег или коммит (необязательно, иначе основная ветка)
+
+	Include  []string // Glob-шаблоны путей lock-файлов, которые нужно сканировать (необязательно, поддерживается "**")
+	Exclude  []string // Glob-шаблоны путей, которые нужно пропустить (необязательно, если не указаны - пропускаются node_modules, vendor, фикстуры и примеры)
+	MaxDepth *int     // Максимальная глубина вложенности папок (необязательно, 0 - только корень)
+}
+
+type getContentsFunc func(ctx context.Context, path string, data UserInfo) (Directory, error)
+type getDownload func(ctx context.Context, file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error)
+type getFilesFunc func(ctx context.Context, data UserInfo) ([]DepFile, error)
+type resolveRefFunc func(ctx context.Context, data UserInfo) (RefInfo, error)
+type listReposFunc func(ctx context.Context, data UserInfo) ([]RemoteRepo, error)
+
+// Зафиксированное состояние репозитория, из которого берутся файлы
+type RefInfo struct {
+	Ref    string // Запрошенная ветка, тег или коммит (или ветка по умолчанию)
+	Commit string // SHA коммита
 }
 
-type getContentsFunc func(path string, data UserInfo) (Directory, error)
-type getDownload func(file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error)
+// Репозиторий организации или пользователя в git-сервисе
+type RemoteRepo struct {
+	Host          string // Адрес инстанса git-сервиса: Id репозиториев уникальны только в его пределах
+	Id            int64  // Id репозитория в git-сервисе
+	Name          string // Наименование репозитория без указания владельца
+	DefaultBranch string
+	Archived      bool
+	Fork          bool
+}
+
+// Какие репозитории пропускать при получении списка репозиториев владельца
+type ListReposOptions struct {
+	SkipArchived bool // Пропускать архивные репозитории
+	SkipForks    bool // Пропускать форки
+}
 
 var gitGetContents = map[string]getContentsFunc{
-	"github": GitHubGetContents,
+	"gitlab":           GitLabGetContents,
+	"gitea":            GiteaGetContents,
+	"bitbucket":        BitbucketCloudGetContents,
+	"bitbucket-server": BitbucketServerGetContents,
+	"azure":            AzureGetContents,
 }
 
 var gitDownload = map[string]getDownload{
-	"github": GitHubDownload,
+	"gitlab":           GitLabDownload,
+	"gitea":            GiteaDownload,
+	"bitbucket":        BitbucketCloudDownload,
+	"bitbucket-server": BitbucketServerDownload,
+	"azure":            AzureDownload,
+}
+
+// Сервисы, которые получают все файлы репозитория целиком, без обхода директорий через API
+var gitGetFiles = map[string]getFilesFunc{
+	"github": GitHubGetFiles,
+	"git":    GitCloneGetFiles,
+	"local":  LocalGetFiles,
+}
+
+var gitResolveRef = map[string]resolveRefFunc{
+	"github":           GitHubResolveRef,
+	"gitlab":           GitLabResolveRef,
+	"gitea":            GiteaResolveRef,
+	"bitbucket":        BitbucketCloudResolveRef,
+	"bitbucket-server": BitbucketServerResolveRef,
+	"azure":            AzureResolveRef,
+	"git":              GitCloneResolveRef,
+}
+
+// Сервисы, которые умеют перечислять репозитории организации или пользователя
+var gitListRepos = map[string]listReposFunc{
+	"github": GitHubListRepos,
 }
 
 var allowedFiles = maps.Keys(Parsers)
@@ -37,33 +107,145 @@ func getFunctions(service string) (getContentsFunc, getDownload, error) {
 	return getContents, getDownload, nil
 }
 
-// Рекурсивный обход директорий с возвратом путей до файлов
-func recursiveParseDirs(path string, data UserInfo, getter getContentsFunc, downloader getDownload) ([]github.RepositoryContent, error) {
+// Проверка, поддерживается ли git-сервис
+func IsSupportedService(service string) bool {
+	if gitGetFiles[service] != nil {
+		return true
+	}
+
+	_, _, err := getFunctions(service)
+	return err == nil
+}
+
+// Получить SHA коммита, на который указывает ref.
+// Дальше этот SHA передаётся вместо ref, чтобы все файлы были взяты из одного коммита.
+// Для сервисов без понятия ref (например, local) возвращается пустой коммит.
+func ResolveRef(ctx context.Context, service string, user UserInfo) (RefInfo, error) {
+	resolve := gitResolveRef[service]
+	if resolve == nil {
+		return RefInfo{Ref: user.Ref}, nil
+	}
+
+	return resolve(ctx, user)
+}
+
+// Проверка, умеет ли git-сервис перечислять репозитории владельца
+func CanListRepositories(service string) bool {
+	return gitListRepos[service] != nil
+}
+
+// Получить репозитории организации или пользователя из поля User
+func ListRepositories(ctx context.Context, service string, user UserInfo, opts ListReposOptions) ([]RemoteRepo, error) {
+	listRepos := gitListRepos[service]
+	if listRepos == nil {
+		return nil, fmt.Errorf("сервис %s не поддерживает получение списка репозиториев", service)
+	}
+
+	repos, err := listRepos(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(repos, func(repo RemoteRepo) bool {
+		return (opts.SkipArchived && repo.Archived) || (opts.SkipForks && repo.Fork)
+	}), nil
+}
+
+// Кол-во одновременных запросов к git-сервису при обходе репозитория по умолчанию
+const defaultTraversalConcurrency = 8
+
+// Получить ограничение на кол-во одновременных запросов к git-сервису.
+// Задаётся переменной окружения TRAVERSAL_CONCURRENCY.
+func traversalConcurrency() int {
+	concurrency, err := strconv.Atoi(os.Getenv("TRAVERSAL_CONCURRENCY"))
+	if err != nil || concurrency < 1 {
+		return defaultTraversalConcurrency
+	}
+
+	return concurrency
+}
+
+// Параллельный обход директорий с ограничением на кол-во одновременных запросов
+type dirWalker struct {
+	ctx        context.Context
+	sem        *semaphore.Weighted
+	data       UserInfo
+	filter     pathFilter
+	getter     getContentsFunc
+	downloader getDownload
+}
+
+// Выполнить запрос к git-сервису, заняв слот семафора
+func (w *dirWalker) limited(action func() error) error {
+	if err := w.sem.Acquire(w.ctx, 1); err != nil {
+		return err
+	}
+	defer w.sem.Release(1)
+
+	return action()
+}
+
+// Обход директории с возвратом найденных файлов.
+// Слот семафора занимается только на время запроса, а не на время ожидания вложенных папок,
+// поэтому глубина рекурсии не может исчерпать слоты.
+func (w *dirWalker) walk(path string) ([]DepFile, error) {
 	// Получаем содержимое текущей папки
-	dir, err := getter(path, data)
+	var dir Directory
+	err := w.limited(func() (err error) {
+		dir, err = w.getter(w.ctx, path, w.data)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Создаём массив под файлы
-	files := make([]github.RepositoryContent, 0)
+	var matched []github.RepositoryContent
 	for _, iterFile := range dir.files {
-		if slices.Contains(allowedFiles, *iterFile.Name) {
-			// Для каждого файла вызываем ф-ию, чтобы получить содержимое этих файлов
-			file, err := downloader(iterFile, data)
-			if err != nil {
-				return nil, err
-			}
-			files = append(files, file)
+		if slices.Contains(allowedFiles, iterFile.GetName()) && w.filter.allowFile(iterFile.GetPath()) {
+			matched = append(matched, iterFile)
 		}
 	}
 
-	// Рекурсивно изучаем папки дальше
-	for _, dir := range dir.folders {
-		childFolder, err := recursiveParseDirs(*dir.Path, data, getter, downloader)
-		if err != nil {
-			return nil, err
+	// Отбрасываем папки до запросов к их содержимому
+	var folders []github.RepositoryContent
+	for _, folder := range dir.folders {
+		if w.filter.allowDir(folder.GetPath()) {
+			folders = append(folders, folder)
 		}
+	}
+
+	// Результаты раскладываются по индексам, чтобы порядок не зависел от порядка завершения запросов
+	files := make([]DepFile, len(matched))
+	childFolders := make([][]DepFile, len(folders))
+
+	group, ctx := errgroup.WithContext(w.ctx)
+	child := *w
+	child.ctx = ctx
+
+	// Для каждого файла вызываем ф-ию, чтобы получить содержимое этих файлов
+	for i, iterFile := range matched {
+		group.Go(func() error {
+			return child.limited(func() error {
+				file, err := w.downloader(ctx, iterFile, w.data)
+				files[i], err = toDepFile(file, err)
+				return err
+			})
+		})
+	}
+
+	// Рекурсивно изучаем папки дальше
+	for i, folder := range folders {
+		group.Go(func() (err error) {
+			childFolders[i], err = child.walk(folder.GetPath())
+			return err
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	for _, childFolder := range childFolders {
 		files = append(files, childFolder...)
 	}
 
@@ -71,17 +253,82 @@ func recursiveParseDirs(path string, data UserInfo, getter getContentsFunc, down
 	return files, nil
 }
 
+// Рекурсивный обход директорий с возвратом путей до файлов
+func recursiveParseDirs(ctx context.Context, path string, data UserInfo, filter pathFilter, getter getContentsFunc, downloader getDownload) ([]DepFile, error) {
+	walker := dirWalker{
+		ctx:        ctx,
+		sem:        semaphore.NewWeighted(int64(traversalConcurrency())),
+		data:       data,
+		filter:     filter,
+		getter:     getter,
+		downloader: downloader,
+	}
+
+	return walker.walk(path)
+}
+
+// Ошибка получения содержимого отдельного файла.
+// Не прерывает сканирование, а указывается у источника.
+type fileContentError struct {
+	err error
+}
+
+func (e *fileContentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fileContentError) Unwrap() error {
+	return e.err
+}
+
+// Преобразовать файл git-сервиса в файл зависимостей.
+// Ошибка загрузки содержимого сохраняется у файла, остальные ошибки возвращаются.
+func toDepFile(file github.RepositoryContent, err error) (DepFile, error) {
+	var contentErr *fileContentError
+	if err != nil && !errors.As(err, &contentErr) {
+		return DepFile{}, err
+	}
+
+	depFile := DepFile{
+		Name: file.GetName(),
+		Path: file.GetPath(),
+	}
+
+	content, decodeErr := file.GetContent()
+	switch {
+	case contentErr != nil:
+		depFile.Error = fmt.Errorf("не удалось скачать файл: %w", contentErr.err)
+	case file.GetEncoding() == "none":
+		depFile.Error = fmt.Errorf("файл слишком большой, git-сервис не отдал его содержимое")
+	case decodeErr != nil:
+		depFile.Error = fmt.Errorf("не удалось получить содержимое файла: %w", decodeErr)
+	default:
+		depFile.Content = content
+	}
+
+	return depFile, nil
+}
+
 // Получить список подходящих файлов из репозитория
-func GetFilesFromRepository(service string, user UserInfo) ([]github.RepositoryContent, error) {
+func GetFilesFromRepository(ctx context.Context, service string, user UserInfo) ([]DepFile, error) {
+	if getFiles := gitGetFiles[service]; getFiles != nil {
+		files, err := getFiles(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+
+		return dropLockedManifests(files), nil
+	}
+
 	getContents, getDownload, err := getFunctions(service)
 	if err != nil {
 		return nil, err
 	}
 
-	files, err := recursiveParseDirs("/", user, getContents, getDownload)
+	files, err := recursiveParseDirs(ctx, "/", user, newPathFilter(user), getContents, getDownload)
 	if err != nil {
 		return nil, err
 	}
 
-	return files, nil
+	return dropLockedManifests(files), nil
 }
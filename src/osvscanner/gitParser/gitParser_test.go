@@ -0,0 +1,125 @@
+package gitParser
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"path"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v62/github"
+)
+
+// Фейковый git-сервис: содержимое папок по пути, каждый запрос выполняется со случайной задержкой
+type fakeTree map[string][]string
+
+func (tree fakeTree) getContents(ctx context.Context, dirPath string, data UserInfo) (Directory, error) {
+	select {
+	case <-time.After(time.Duration(rand.Intn(5)) * time.Millisecond):
+	case <-ctx.Done():
+		return Directory{}, ctx.Err()
+	}
+
+	var dir Directory
+	for _, name := range tree[dirPath] {
+		entry := github.RepositoryContent{Name: github.String(path.Base(name)), Path: github.String(name)}
+		if _, isDir := tree[name]; isDir {
+			dir.folders = append(dir.folders, entry)
+		} else {
+			dir.files = append(dir.files, entry)
+		}
+	}
+
+	return dir, nil
+}
+
+func (tree fakeTree) download(ctx context.Context, file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error) {
+	time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+	return file, nil
+}
+
+func TestRecursiveParseDirsOrder(t *testing.T) {
+	t.Setenv("TRAVERSAL_CONCURRENCY", "4")
+
+	tree := fakeTree{
+		"/":                {"a", "b", "package-lock.json", "README.md", "node_modules"},
+		"a":                {"a/x", "a/requirements.txt", "a/yarn.lock"},
+		"a/x":              {"a/x/package-lock.json"},
+		"b":                {"b/pnpm-lock.yaml", "b/package-lock.json"},
+		"node_modules":     {"node_modules/pkg"},
+		"node_modules/pkg": {"node_modules/pkg/package-lock.json"},
+	}
+
+	// Сначала файлы папки, затем содержимое вложенных папок в порядке их следования
+	want := []string{
+		"package-lock.json",
+		"a/requirements.txt",
+		"a/yarn.lock",
+		"a/x/package-lock.json",
+		"b/pnpm-lock.yaml",
+		"b/package-lock.json",
+	}
+
+	for i := 0; i < 20; i++ {
+		files, err := recursiveParseDirs(context.Background(), "/", UserInfo{}, newPathFilter(UserInfo{}), tree.getContents, tree.download)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, file := range files {
+			got = append(got, file.Path)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("проход %d: получены файлы %v, ожидались %v", i, got, want)
+		}
+	}
+}
+
+func TestRecursiveParseDirsCancelled(t *testing.T) {
+	tree := fakeTree{
+		"/":     {"a", "b"},
+		"a":     {"a/package-lock.json"},
+		"b":     {"b/c"},
+		"b/c":   {"b/c/d"},
+		"b/c/d": {"b/c/d/package-lock.json"},
+	}
+
+	// Папка b/c/d отвечает только после отмены контекста
+	getter := func(ctx context.Context, dirPath string, data UserInfo) (Directory, error) {
+		if dirPath == "b/c/d" {
+			<-ctx.Done()
+			return Directory{}, ctx.Err()
+		}
+		return tree.getContents(ctx, dirPath, data)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		files, err := recursiveParseDirs(ctx, "/", UserInfo{}, newPathFilter(UserInfo{}), getter, tree.download)
+		if files != nil {
+			t.Errorf("при отмене получены файлы %v", files)
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ожидалась ошибка %v, получена %v", context.Canceled, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("обход не завершился после отмены контекста")
+	}
+
+	// Уже отменённый контекст не должен приводить к обходу
+	files, err := recursiveParseDirs(ctx, "/", UserInfo{}, newPathFilter(UserInfo{}), tree.getContents, tree.download)
+	if !errors.Is(err, context.Canceled) || files != nil {
+		t.Errorf("для отменённого контекста получены файлы %v и ошибка %v", files, err)
+	}
+}
@@ -0,0 +1,200 @@
+package gitParser
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"web-scan-worker/src/internal/cachedregexp"
+)
+
+// Максимальное время на получение репозитория
+const gitCloneTimeout = 10 * time.Minute
+
+var ErrGitURLMissing = errors.New("не указан адрес git-репозитория")
+var ErrGitInvalidArgument = errors.New("недопустимый адрес git-репозитория или ref")
+var ErrGitProtocolNotAllowed = errors.New("протокол git-репозитория не разрешён")
+
+// Разрешены ли репозитории по протоколу file (в т.ч. локальные пути) на машине воркера.
+// Задаётся переменной окружения GIT_ALLOW_FILE_PROTOCOL.
+func gitFileProtocolAllowed() bool {
+	return os.Getenv("GIT_ALLOW_FILE_PROTOCOL") == "true"
+}
+
+// Проверить адрес репозитория и ref перед передачей в git.
+// Значения, начинающиеся с "-", git может принять за опции (например --upload-pack), поэтому они запрещены.
+func validateGitSource(data UserInfo) error {
+	if data.Url == "" {
+		return ErrGitURLMissing
+	}
+	if strings.HasPrefix(data.Url, "-") || strings.HasPrefix(data.Ref, "-") {
+		return ErrGitInvalidArgument
+	}
+
+	scheme, _, found := strings.Cut(data.Url, "://")
+	switch {
+	case found && (scheme == "https" || scheme == "ssh"):
+	case found && scheme == "file" && gitFileProtocolAllowed():
+	case !found && cachedregexp.MustCompile(`^[\w.-]+@[\w.-]+:`).MatchString(data.Url):
+		// scp-подобный адрес ssh: git@host:owner/repo.git
+	default:
+		return fmt.Errorf("%w: %s", ErrGitProtocolNotAllowed, data.Url)
+	}
+
+	return nil
+}
+
+// Выполнить git-команду в указанной папке и вернуть её вывод
+func runGit(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("ошибка выполнения git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return string(output), nil
+}
+
+// Переменные окружения для git.
+// Токен передаётся заголовком через конфигурацию из окружения, чтобы он не попал ни в URL, ни в аргументы процесса.
+func gitEnv(data UserInfo) []string {
+	// Ограничиваем протоколы и для самого адреса, и для перенаправлений и подмодулей
+	protocols := "https:ssh"
+	if gitFileProtocolAllowed() {
+		protocols += ":file"
+	}
+	env := []string{"GIT_TERMINAL_PROMPT=0", "GIT_ALLOW_PROTOCOL=" + protocols}
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		env = append(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+
+	if data.Token != "" && strings.HasPrefix(data.Url, "https://") {
+		login := data.Login
+		if login == "" {
+			login = "git"
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(login + ":" + data.Token))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials,
+		)
+	}
+
+	return env
+}
+
+// Получить SHA коммита, на который указывает ref, без загрузки репозитория
+func GitCloneResolveRef(ctx context.Context, data UserInfo) (RefInfo, error) {
+	if err := validateGitSource(data); err != nil {
+		return RefInfo{}, err
+	}
+
+	ref := data.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	// Если передан SHA коммита, то он уже зафиксирован
+	if cachedregexp.MustCompile(`^[0-9a-fA-F]{40}$`).MatchString(ref) {
+		return RefInfo{Ref: ref, Commit: ref}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gitCloneTimeout)
+	defer cancel()
+
+	// ls-remote сравнивает шаблоны с концом имени ref, поэтому запрашиваем полные имена
+	// и выбираем точное совпадение: иначе для main подошла бы и ветка feature/main
+	var candidates []string
+	switch {
+	case ref == "HEAD" || strings.HasPrefix(ref, "refs/"):
+		candidates = []string{ref + "^{}", ref}
+	default:
+		candidates = []string{"refs/tags/" + ref + "^{}", "refs/tags/" + ref, "refs/heads/" + ref}
+	}
+
+	args := append([]string{"ls-remote", "--symref", "--", data.Url}, candidates...)
+	output, err := runGit(ctx, "", gitEnv(data), args...)
+	if err != nil {
+		return RefInfo{}, err
+	}
+
+	found := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		sha, name, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+
+		// Для HEAD узнаём, на какую ветку он указывает
+		if target, isSymref := strings.CutPrefix(sha, "ref: "); isSymref {
+			if name == "HEAD" && ref == "HEAD" {
+				ref = strings.TrimPrefix(target, "refs/heads/")
+			}
+			continue
+		}
+
+		found[name] = sha
+	}
+
+	// Для аннотированных тегов берём коммит, на который указывает тег
+	commit := ""
+	for _, candidate := range candidates {
+		if sha, ok := found[candidate]; ok {
+			commit = sha
+			break
+		}
+	}
+
+	if commit == "" {
+		return RefInfo{}, fmt.Errorf("не найден ref %s в %s", ref, data.Url)
+	}
+
+	return RefInfo{Ref: ref, Commit: commit}, nil
+}
+
+// Получить файлы из произвольного git-репозитория (https, ssh, а также file://, если это разрешено GIT_ALLOW_FILE_PROTOCOL).
+// Вместо обхода через API делаем неглубокую загрузку одного ref во временную папку и обходим рабочее дерево.
+func GitCloneGetFiles(ctx context.Context, data UserInfo) ([]DepFile, error) {
+	if err := validateGitSource(data); err != nil {
+		return nil, err
+	}
+
+	ref := data.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	dir, err := os.MkdirTemp("", "web-scan-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(ctx, gitCloneTimeout)
+	defer cancel()
+
+	fmt.Println("Пробуем получить", ref, "из", data.Url)
+	env := gitEnv(data)
+	if _, err := runGit(ctx, dir, env, "init", "--quiet"); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, dir, env, "fetch", "--quiet", "--depth", "1", "--no-tags", "--", data.Url, ref); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, dir, env, "-c", "advice.detachedHead=false", "checkout", "--quiet", "FETCH_HEAD"); err != nil {
+		return nil, err
+	}
+
+	return walkLocalDir(dir, newPathFilter(data))
+}
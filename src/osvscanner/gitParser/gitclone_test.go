@@ -0,0 +1,170 @@
+package gitParser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Создать bare-репозиторий с одним коммитом на ветке main
+func newBareRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git не установлен")
+	}
+
+	root := t.TempDir()
+	bare := filepath.Join(root, "repo.git")
+	work := filepath.Join(root, "work")
+
+	git := func(dir string, args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if _, err := runGit(context.Background(), dir, nil, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git(root, "init", "--quiet", "--bare", "--initial-branch=main", bare)
+	git(root, "init", "--quiet", "--initial-branch=main", work)
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(work, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(work, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git(work, "add", "-A")
+	git(work, "commit", "--quiet", "-m", "init")
+	git(work, "push", "--quiet", bare, "main")
+
+	return bare
+}
+
+func TestGitCloneGetFiles(t *testing.T) {
+	bare := newBareRepo(t, map[string]string{
+		"package-lock.json":        `{"lockfileVersion":3,"packages":{}}`,
+		"backend/requirements.txt": "flask==2.0.0\n",
+		"README.md":                "readme",
+	})
+	t.Setenv("GIT_ALLOW_FILE_PROTOCOL", "true")
+
+	for _, ref := range []string{"", "main"} {
+		files, err := GetFilesFromRepository(context.Background(), "git", UserInfo{Url: "file://" + bare, Ref: ref})
+		if err != nil {
+			t.Fatalf("ref %q: %v", ref, err)
+		}
+
+		got := map[string]string{}
+		for _, file := range files {
+			got[file.Path] = file.Content
+		}
+		if len(got) != 2 || got["backend/requirements.txt"] != "flask==2.0.0\n" {
+			t.Errorf("ref %q: получены файлы %v", ref, got)
+		}
+	}
+}
+
+func TestGitCloneResolveRef(t *testing.T) {
+	bare := newBareRepo(t, map[string]string{"package-lock.json": "{}"})
+	t.Setenv("GIT_ALLOW_FILE_PROTOCOL", "true")
+
+	ref, err := ResolveRef(context.Background(), "git", UserInfo{Url: "file://" + bare})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Ref != "main" || len(ref.Commit) != 40 {
+		t.Errorf("получен ref %+v", ref)
+	}
+	mainCommit := ref.Commit
+
+	// Ветка feature/main с другим коммитом и аннотированный тег на main
+	work := filepath.Join(t.TempDir(), "work")
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		output, err := runGit(context.Background(), dir, nil, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(output)
+	}
+	git("", "clone", "--quiet", bare, work)
+	git(work, "tag", "-a", "-m", "release", "v1")
+	git(work, "checkout", "--quiet", "-b", "feature/main")
+	git(work, "commit", "--quiet", "--allow-empty", "-m", "feature")
+	featureCommit := git(work, "rev-parse", "HEAD")
+	git(work, "push", "--quiet", "origin", "feature/main", "v1")
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"main", mainCommit},
+		{"refs/heads/main", mainCommit},
+		{"feature/main", featureCommit},
+		{"v1", mainCommit},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolveRef(context.Background(), "git", UserInfo{Url: "file://" + bare, Ref: tt.ref})
+		if err != nil {
+			t.Fatalf("ref %s: %v", tt.ref, err)
+		}
+		if got.Commit != tt.want {
+			t.Errorf("ref %s: получен коммит %s, ожидался %s", tt.ref, got.Commit, tt.want)
+		}
+	}
+
+	if _, err := ResolveRef(context.Background(), "git", UserInfo{Url: "file://" + bare, Ref: "feature"}); err == nil {
+		t.Error("ожидалась ошибка для несуществующего ref")
+	}
+}
+
+func TestGitCloneRejectsUnsafeSources(t *testing.T) {
+	bare := newBareRepo(t, map[string]string{"package-lock.json": "{}"})
+	marker := filepath.Join(t.TempDir(), "pwned")
+
+	tests := []struct {
+		name      string
+		user      UserInfo
+		allowFile bool
+		want      error
+	}{
+		{"опция вместо адреса", UserInfo{Url: "--upload-pack=touch " + marker + ";"}, true, ErrGitInvalidArgument},
+		{"опция вместо ref", UserInfo{Url: "file://" + bare, Ref: "--upload-pack=touch " + marker}, true, ErrGitInvalidArgument},
+		{"file без разрешения", UserInfo{Url: "file://" + bare}, false, ErrGitProtocolNotAllowed},
+		{"локальный путь без разрешения", UserInfo{Url: bare}, false, ErrGitProtocolNotAllowed},
+		{"ext", UserInfo{Url: "ext::sh -c touch% " + marker}, true, ErrGitProtocolNotAllowed},
+		{"http", UserInfo{Url: "http://example.com/repo.git"}, true, ErrGitProtocolNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allow := ""
+			if tt.allowFile {
+				allow = "true"
+			}
+			t.Setenv("GIT_ALLOW_FILE_PROTOCOL", allow)
+
+			_, err := GetFilesFromRepository(context.Background(), "git", tt.user)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("ожидалась ошибка %v, получена %v", tt.want, err)
+			}
+
+			_, err = ResolveRef(context.Background(), "git", tt.user)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("ResolveRef: ожидалась ошибка %v, получена %v", tt.want, err)
+			}
+			if _, err := os.Stat(marker); err == nil {
+				t.Fatal("git выполнил команду из параметров запроса")
+			}
+		})
+	}
+}
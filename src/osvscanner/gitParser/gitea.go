@@ -0,0 +1,129 @@
+package gitParser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v62/github"
+)
+
+var ErrGiteaURLMissing = errors.New("не указан адрес инстанса Gitea/Forgejo")
+
+// Получить адрес репозитория в API Gitea/Forgejo
+func giteaRepoURL(data UserInfo) (string, error) {
+	baseURL := data.Url
+	if baseURL == "" {
+		baseURL = os.Getenv("GITEA_URL")
+	}
+	if baseURL == "" {
+		return "", ErrGiteaURLMissing
+	}
+
+	return strings.TrimSuffix(baseURL, "/") + "/api/v1/repos/" + url.PathEscape(data.User) + "/" + url.PathEscape(data.Repo), nil
+}
+
+// Заголовки авторизации
+func giteaHeaders(data UserInfo) map[string]string {
+	if data.Token == "" {
+		return nil
+	}
+
+	return map[string]string{"Authorization": "token " + data.Token}
+}
+
+// Получить адрес содержимого по пути с учётом ref
+func giteaContentsURL(path string, data UserInfo) (string, error) {
+	repoURL, err := giteaRepoURL(data)
+	if err != nil {
+		return "", err
+	}
+
+	rawURL := repoURL + "/contents"
+	if escaped := escapePath(path); escaped != "" {
+		rawURL += "/" + escaped
+	}
+	if data.Ref != "" {
+		rawURL += "?ref=" + url.QueryEscape(data.Ref)
+	}
+
+	return rawURL, nil
+}
+
+// Получить SHA коммита, на который указывает ref
+func GiteaResolveRef(ctx context.Context, data UserInfo) (RefInfo, error) {
+	repoURL, err := giteaRepoURL(data)
+	if err != nil {
+		return RefInfo{}, err
+	}
+
+	ref := data.Ref
+	if ref == "" {
+		var repo struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if _, err := restGetJSON(ctx, repoURL, giteaHeaders(data), &repo); err != nil {
+			return RefInfo{}, err
+		}
+		ref = repo.DefaultBranch
+	}
+
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	if _, err := restGetJSON(ctx, repoURL+"/git/commits/"+url.PathEscape(ref), giteaHeaders(data), &commit); err != nil {
+		return RefInfo{}, err
+	}
+
+	return RefInfo{Ref: ref, Commit: commit.SHA}, nil
+}
+
+// Получить содержимое папки.
+// Ответ Gitea совместим по формату с GitHub Contents API.
+func GiteaGetContents(ctx context.Context, path string, data UserInfo) (Directory, error) {
+	fmt.Println("Пробуем получить содержимое из \"" + path + "\"")
+
+	rawURL, err := giteaContentsURL(path, data)
+	if err != nil {
+		return Directory{}, err
+	}
+
+	var dir []github.RepositoryContent
+	if _, err := restGetJSON(ctx, rawURL, giteaHeaders(data), &dir); err != nil {
+		return Directory{}, err
+	}
+
+	var files []github.RepositoryContent
+	var folders []github.RepositoryContent
+
+	for _, elem := range dir {
+		if elem.GetType() == "file" {
+			files = append(files, elem)
+		}
+		if elem.GetType() == "dir" {
+			folders = append(folders, elem)
+		}
+	}
+
+	return Directory{folders: folders, files: files}, nil
+}
+
+// Получить содержимое файла.
+func GiteaDownload(ctx context.Context, file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error) {
+	fmt.Println("Пробуем скачать ", file.GetPath())
+
+	rawURL, err := giteaContentsURL(file.GetPath(), data)
+	if err != nil {
+		return github.RepositoryContent{}, err
+	}
+
+	var giteaFile github.RepositoryContent
+	if _, err := restGetJSON(ctx, rawURL, giteaHeaders(data), &giteaFile); err != nil {
+		return github.RepositoryContent{}, err
+	}
+
+	return giteaFile, nil
+}
@@ -2,10 +2,17 @@ package gitParser
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
 	"strings"
 
 	"github.com/google/go-github/v62/github"
+	"golang.org/x/sync/errgroup"
 )
 
 type Directory struct {
@@ -13,16 +20,103 @@ type Directory struct {
 	files   []github.RepositoryContent
 }
 
+// Адрес GitHub по умолчанию, если не указан ни в запросе, ни в GITHUB_URL
+const gitHubDefaultURL = "https://github.com"
+
+// Максимальное число файлов, которое GitHub возвращает при сравнении коммитов
+const githubCompareFilesLimit = 300
+
+// Получить адрес инстанса GitHub
+func gitHubURL(data UserInfo) string {
+	baseURL := data.Url
+	if baseURL == "" {
+		baseURL = os.Getenv("GITHUB_URL")
+	}
+	if baseURL == "" {
+		baseURL = gitHubDefaultURL
+	}
+
+	return strings.TrimSuffix(baseURL, "/")
+}
+
+// Создать клиент GitHub API с указанным токеном.
+// Для GitHub Enterprise Server к адресу инстанса добавляется /api/v3/, адрес загрузок берётся из GITHUB_UPLOAD_URL.
+func newGitHubAPIClient(data UserInfo, token string) (*github.Client, error) {
+	client := github.NewClient(nil).WithAuthToken(token)
+
+	baseURL := gitHubURL(data)
+	if baseURL == gitHubDefaultURL || baseURL == "https://api.github.com" {
+		return client, nil
+	}
+
+	uploadURL := os.Getenv("GITHUB_UPLOAD_URL")
+	if uploadURL == "" {
+		uploadURL = baseURL
+	}
+
+	client, err := client.WithEnterpriseURLs(baseURL, uploadURL)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный адрес GitHub Enterprise Server %s: %w", baseURL, err)
+	}
+
+	return client, nil
+}
+
+// Дополнить ошибку GitHub API понятным описанием: инстанс недоступен, токен не принят или не авторизован для SSO
+func githubError(data UserInfo, err error) error {
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		if sso := respErr.Response.Header.Get("X-GitHub-SSO"); strings.HasPrefix(sso, "required") {
+			_, ssoURL, _ := strings.Cut(sso, "url=")
+			return fmt.Errorf("организация %s использует SAML SSO, токен нужно авторизовать для неё: %s", data.User, ssoURL)
+		}
+		if respErr.Response.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("GitHub по адресу %s не принял токен: %w", gitHubURL(data), err)
+		}
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("GitHub по адресу %s недоступен: %w", gitHubURL(data), err)
+	}
+
+	return err
+}
+
+// Создать клиент GitHub. Один клиент переиспользуется в рамках всего сканирования.
+// Если токен не передан, а воркер настроен как GitHub App, используется токен установки приложения.
+func newGitHubClient(ctx context.Context, data UserInfo) (*github.Client, error) {
+	token := data.Token
+	if token == "" {
+		app, err := loadGitHubApp()
+		if err != nil {
+			return nil, err
+		}
+		if app != nil && githubAppServes(data) {
+			token, err = app.installationToken(ctx, data)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return newGitHubAPIClient(data, token)
+}
+
 // Получить содержимое папки
-func GitHubGetContents(path string, data UserInfo) (Directory, error) {
-	if strings.Contains(path, "..") {
-		fmt.Println("Получение содержимого из", path, "невозможно по причине запрета GitHub на содержание в пути \"..\"")
+func githubGetContents(ctx context.Context, client *github.Client, dirPath string, data UserInfo) (Directory, error) {
+	if strings.Contains(dirPath, "..") {
+		fmt.Println("Получение содержимого из", dirPath, "невозможно по причине запрета GitHub на содержание в пути \"..\"")
 		return Directory{}, nil
 	}
-	fmt.Println("Пробуем получить содержимое из \"" + path + "\"")
-	client := github.NewClient(nil).WithAuthToken(data.Token)
+	fmt.Println("Пробуем получить содержимое из \"" + dirPath + "\"")
 
-	_, dir, _, err := client.Repositories.GetContents(context.Background(), data.User, data.Repo, path, nil)
+	opts := &github.RepositoryContentGetOptions{Ref: data.Ref}
+	var dir []*github.RepositoryContent
+	err := githubCall(ctx, data, func() (resp *github.Response, err error) {
+		_, dir, resp, err = client.Repositories.GetContents(ctx, data.User, data.Repo, dirPath, opts)
+		return resp, err
+	})
 
 	if err != nil {
 		return Directory{}, err
@@ -40,21 +134,531 @@ func GitHubGetContents(path string, data UserInfo) (Directory, error) {
 		}
 	}
 
-	// fmt.Println(files)
-
 	return Directory{folders: folders, files: files}, nil
 }
 
 // Получить содержимое файла.
-func GitHubDownload(file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error) {
+func githubDownload(ctx context.Context, client *github.Client, file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error) {
 	fmt.Println("Пробуем скачать ", file.GetPath())
-	client := github.NewClient(nil).WithAuthToken(data.Token)
 
-	githubFile, _, _, err := client.Repositories.GetContents(context.Background(), data.User, data.Repo, file.GetPath(), nil)
+	opts := &github.RepositoryContentGetOptions{Ref: data.Ref}
+	var githubFile *github.RepositoryContent
+	err := githubCall(ctx, data, func() (resp *github.Response, err error) {
+		githubFile, _, resp, err = client.Repositories.GetContents(ctx, data.User, data.Repo, file.GetPath(), opts)
+		return resp, err
+	})
 
 	if err != nil {
 		return github.RepositoryContent{}, err
 	}
 
+	// Для файлов больше 1 МБ Contents API не возвращает содержимое, скачиваем их как blob
+	if githubFile.GetType() == "file" && githubFile.GetEncoding() == "none" {
+		fmt.Println("Файл", file.GetPath(), "больше 1 МБ, скачиваем как blob")
+		content, err := githubBlob(ctx, client, githubFile.GetSHA(), data)
+		if err != nil {
+			var respErr *github.ErrorResponse
+			if !errors.As(err, &respErr) {
+				return github.RepositoryContent{}, err
+			}
+			// Ошибка будет указана у источника вместо общего сообщения о размере файла
+			fmt.Println("Не удалось скачать", file.GetPath(), "как blob:", err)
+			return *githubFile, &fileContentError{err: err}
+		}
+
+		githubFile.Content = github.String(string(content))
+		githubFile.Encoding = nil
+	}
+
 	return *githubFile, nil
 }
+
+// Скачать blob-объект без перекодирования. Git Data API отдаёт файлы размером до 100 МБ.
+func githubBlob(ctx context.Context, client *github.Client, sha string, data UserInfo) ([]byte, error) {
+	var content []byte
+	err := githubCall(ctx, data, func() (resp *github.Response, err error) {
+		content, resp, err = client.Git.GetBlobRaw(ctx, data.User, data.Repo, sha)
+		return resp, err
+	})
+
+	return content, err
+}
+
+// Получить ref для запроса. Если он не указан, берём ветку по умолчанию.
+func githubRef(ctx context.Context, client *github.Client, data UserInfo) (string, error) {
+	if data.Ref != "" {
+		return data.Ref, nil
+	}
+
+	var repo *github.Repository
+	err := githubCall(ctx, data, func() (resp *github.Response, err error) {
+		repo, resp, err = client.Repositories.Get(ctx, data.User, data.Repo)
+		return resp, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return repo.GetDefaultBranch(), nil
+}
+
+// Получить SHA коммита, на который указывает ref
+func GitHubResolveRef(ctx context.Context, data UserInfo) (RefInfo, error) {
+	client, err := newGitHubClient(ctx, data)
+	if err != nil {
+		return RefInfo{}, err
+	}
+
+	ref, err := githubRef(ctx, client, data)
+	if err != nil {
+		return RefInfo{}, err
+	}
+
+	var sha string
+	err = githubCall(ctx, data, func() (resp *github.Response, err error) {
+		sha, resp, err = client.Repositories.GetCommitSHA1(ctx, data.User, data.Repo, ref, "")
+		return resp, err
+	})
+	if err != nil {
+		return RefInfo{}, err
+	}
+
+	return RefInfo{Ref: ref, Commit: sha}, nil
+}
+
+// Получить подходящие файлы репозитория.
+// Всё дерево репозитория запрашивается одним вызовом Git Trees API, после чего скачиваются
+// только подходящие blob-объекты. Если GitHub обрезал дерево, выполняем обычный обход директорий.
+func GitHubGetFiles(ctx context.Context, data UserInfo) ([]DepFile, error) {
+	return githubGetRepoFiles(ctx, data, newPathFilter(data), 0)
+}
+
+// Получить подходящие файлы репозитория и его подмодулей.
+// depth - уровень вложенности подмодуля (0 - основной репозиторий).
+func githubGetRepoFiles(ctx context.Context, data UserInfo, filter pathFilter, depth int) ([]DepFile, error) {
+	client, err := newGitHubClient(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := githubRef(ctx, client, data)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Пробуем получить дерево", ref)
+	var tree *github.Tree
+	err = githubCall(ctx, data, func() (resp *github.Response, err error) {
+		tree, resp, err = client.Git.GetTree(ctx, data.User, data.Repo, ref, true)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data.Ref = ref
+	var files []DepFile
+	if tree.GetTruncated() {
+		fmt.Println("Дерево репозитория слишком большое, переходим к обходу директорий")
+
+		files, err = recursiveParseDirs(ctx, "/", data, filter,
+			func(ctx context.Context, dirPath string, data UserInfo) (Directory, error) {
+				return githubGetContents(ctx, client, dirPath, data)
+			},
+			func(ctx context.Context, file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error) {
+				return githubDownload(ctx, client, file, data)
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		files, err = githubDownloadTreeFiles(ctx, client, data, filter, tree)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	submoduleFiles, err := githubSubmoduleFiles(ctx, client, data, filter, tree, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(files, submoduleFiles...), nil
+}
+
+// Скачать подходящие файлы из полного дерева репозитория
+func githubDownloadTreeFiles(ctx context.Context, client *github.Client, data UserInfo, filter pathFilter, tree *github.Tree) ([]DepFile, error) {
+	var matched []*github.TreeEntry
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" && slices.Contains(allowedFiles, path.Base(entry.GetPath())) && filter.allowFile(entry.GetPath()) {
+			matched = append(matched, entry)
+		}
+	}
+
+	// Скачиваем файлы параллельно, сохраняя порядок дерева
+	files := make([]DepFile, len(matched))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(traversalConcurrency())
+	for i, entry := range matched {
+		group.Go(func() error {
+			fmt.Println("Пробуем скачать ", entry.GetPath())
+			content, err := githubBlob(groupCtx, client, entry.GetSHA(), data)
+
+			files[i] = DepFile{
+				Name:    path.Base(entry.GetPath()),
+				Path:    entry.GetPath(),
+				Content: string(content),
+			}
+
+			// Файл, который GitHub отказался отдать, не прерывает сканирование, ошибка указывается у источника
+			if err != nil {
+				var respErr *github.ErrorResponse
+				if !errors.As(err, &respErr) {
+					return err
+				}
+				fmt.Println("Не удалось скачать", entry.GetPath()+":", err)
+				files[i].Error = fmt.Errorf("не удалось скачать файл: %w", err)
+			}
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// Получить файлы зависимостей, изменённые между base и head.
+// Список изменений берётся из Compare API, скачиваются только изменённые lock-файлы.
+func GitHubGetChangedFiles(ctx context.Context, data UserInfo, base string, head string) (ChangedFiles, error) {
+	client, err := newGitHubClient(ctx, data)
+	if err != nil {
+		return ChangedFiles{}, err
+	}
+
+	// Список файлов приходит только вместе с первой страницей коммитов и обрезается на githubCompareFilesLimit файлах
+	var comparison *github.CommitsComparison
+	err = githubCall(ctx, data, func() (resp *github.Response, err error) {
+		comparison, resp, err = client.Repositories.CompareCommits(ctx, data.User, data.Repo, base, head, &github.ListOptions{PerPage: 1})
+		return resp, err
+	})
+	if err != nil {
+		return ChangedFiles{}, err
+	}
+
+	// Список изменений неполный, поэтому сравниваем деревья коммитов целиком
+	if len(comparison.Files) >= githubCompareFilesLimit {
+		fmt.Println("Изменено больше", githubCompareFilesLimit, "файлов, сравниваются деревья коммитов целиком")
+		return compareRepositoryFiles(ctx, "github", data, base, head)
+	}
+
+	filter := newPathFilter(data)
+	var changed ChangedFiles
+	for _, commitFile := range comparison.Files {
+		// Без полного дерева неизвестно, закреплены ли версии манифеста lock-файлом, поэтому манифесты не сравниваются
+		if IsManifest(path.Base(commitFile.GetFilename())) {
+			continue
+		}
+
+		headPath := commitFile.GetFilename()
+		headAllowed := commitFile.GetStatus() != "removed" && slices.Contains(allowedFiles, path.Base(headPath)) && filter.allowFile(headPath)
+
+		basePath := headPath
+		if commitFile.GetPreviousFilename() != "" {
+			basePath = commitFile.GetPreviousFilename()
+		}
+
+		// В базовом коммите добавленного файла нет
+		if commitFile.GetStatus() != "added" && slices.Contains(allowedFiles, path.Base(basePath)) && filter.allowFile(basePath) {
+			file, err := githubDownloadAt(ctx, client, basePath, base, data)
+			if err != nil {
+				return ChangedFiles{}, err
+			}
+
+			// Переименованный файл сравнивается под новым путём, иначе все его уязвимости
+			// окажутся одновременно исправленными и появившимися
+			if headAllowed {
+				file.Path = headPath
+			}
+			changed.Base = append(changed.Base, file)
+		}
+
+		// В проверяемом коммите удалённого файла нет
+		if headAllowed {
+			file, err := githubDownloadAt(ctx, client, headPath, head, data)
+			if err != nil {
+				return ChangedFiles{}, err
+			}
+			changed.Head = append(changed.Head, file)
+		}
+	}
+
+	return changed, nil
+}
+
+// Скачать файл в состоянии указанного ref
+func githubDownloadAt(ctx context.Context, client *github.Client, filePath string, ref string, data UserInfo) (DepFile, error) {
+	data.Ref = ref
+	file, err := githubDownload(ctx, client, github.RepositoryContent{
+		Name: github.String(path.Base(filePath)),
+		Path: github.String(filePath),
+	}, data)
+
+	return toDepFile(file, err)
+}
+
+// Получить репозитории организации или пользователя.
+// От имени GitHub App перечисляются репозитории установки, с токеном пользователя - репозитории
+// организации, собственные репозитории владельца токена (в т.ч. приватные) или публичные репозитории пользователя.
+func GitHubListRepos(ctx context.Context, data UserInfo) ([]RemoteRepo, error) {
+	data.Repo = ""
+	client, err := newGitHubClient(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := githubRepoLister(ctx, client, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []RemoteRepo
+	opts := github.ListOptions{PerPage: 100}
+	for {
+		fmt.Println("Получаем репозитории", data.User+", страница", max(opts.Page, 1))
+
+		var page []*github.Repository
+		var resp *github.Response
+		err := githubCall(ctx, data, func() (_ *github.Response, err error) {
+			page, resp, err = list(opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, repo := range page {
+			// Установка приложения может охватывать репозитории разных владельцев
+			if !strings.EqualFold(repo.GetOwner().GetLogin(), data.User) {
+				continue
+			}
+
+			repos = append(repos, RemoteRepo{
+				Host:          gitHubURL(data),
+				Id:            repo.GetID(),
+				Name:          repo.GetName(),
+				DefaultBranch: repo.GetDefaultBranch(),
+				Archived:      repo.GetArchived(),
+				Fork:          repo.GetFork(),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return repos, nil
+}
+
+// Выбрать способ получения страницы списка репозиториев в зависимости от авторизации и типа владельца
+func githubRepoLister(ctx context.Context, client *github.Client, data UserInfo) (func(opts github.ListOptions) ([]*github.Repository, *github.Response, error), error) {
+	app, err := loadGitHubApp()
+	if err != nil {
+		return nil, err
+	}
+	if data.Token == "" && app != nil {
+		return func(opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+			list, resp, err := client.Apps.ListRepos(ctx, &opts)
+			if err != nil {
+				return nil, resp, err
+			}
+			return list.Repositories, resp, nil
+		}, nil
+	}
+
+	var owner *github.User
+	err = githubCall(ctx, data, func() (resp *github.Response, err error) {
+		owner, resp, err = client.Users.Get(ctx, data.User)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if owner.GetType() == "Organization" {
+		return func(opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+			return client.Repositories.ListByOrg(ctx, data.User, &github.RepositoryListByOrgOptions{ListOptions: opts})
+		}, nil
+	}
+
+	// Приватные репозитории пользователя видны только ему самому
+	if data.Token != "" {
+		var self *github.User
+		err = githubCall(ctx, data, func() (resp *github.Response, err error) {
+			self, resp, err = client.Users.Get(ctx, "")
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.EqualFold(self.GetLogin(), data.User) {
+			return func(opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+				return client.Repositories.ListByAuthenticatedUser(ctx, &github.RepositoryListByAuthenticatedUserOptions{Affiliation: "owner", ListOptions: opts})
+			}, nil
+		}
+	}
+
+	return func(opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+		return client.Repositories.ListByUser(ctx, data.User, &github.RepositoryListByUserOptions{Type: "owner", ListOptions: opts})
+	}, nil
+}
+
+// Получить историю изменений lock-файлов.
+// Для каждого lock-файла берутся последние коммиты, которые его затрагивали, и скачивается его состояние после каждого из них.
+func GitHubGetHistory(ctx context.Context, data UserInfo) ([]FileHistory, error) {
+	client, err := newGitHubClient(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Ref, err = githubRef(ctx, client, data)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := githubLockfilePaths(ctx, client, data, newPathFilter(data))
+	if err != nil {
+		return nil, err
+	}
+
+	histories := make([]FileHistory, 0, len(paths))
+	for _, filePath := range paths {
+		history, err := githubFileHistory(ctx, client, data, filePath)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, history)
+	}
+
+	return histories, nil
+}
+
+// Получить пути подходящих lock-файлов без скачивания их содержимого
+func githubLockfilePaths(ctx context.Context, client *github.Client, data UserInfo, filter pathFilter) ([]string, error) {
+	var tree *github.Tree
+	err := githubCall(ctx, data, func() (resp *github.Response, err error) {
+		tree, resp, err = client.Git.GetTree(ctx, data.User, data.Repo, data.Ref, true)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// История ведётся только для lock-файлов, манифесты с незакреплёнными версиями не учитываются
+	var paths []string
+	if !tree.GetTruncated() {
+		for _, entry := range tree.Entries {
+			if entry.GetType() == "blob" && slices.Contains(allowedFiles, path.Base(entry.GetPath())) && !IsManifest(path.Base(entry.GetPath())) && filter.allowFile(entry.GetPath()) {
+				paths = append(paths, entry.GetPath())
+			}
+		}
+
+		return paths, nil
+	}
+
+	// Дерево обрезано, обходим директории, не скачивая найденные файлы
+	files, err := recursiveParseDirs(ctx, "/", data, filter,
+		func(ctx context.Context, dirPath string, data UserInfo) (Directory, error) {
+			return githubGetContents(ctx, client, dirPath, data)
+		},
+		func(ctx context.Context, file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error) {
+			return file, nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if !IsManifest(file.Name) {
+			paths = append(paths, file.Path)
+		}
+	}
+
+	return paths, nil
+}
+
+// Получить историю одного lock-файла: последние коммиты, которые его затрагивали, и его состояние после них
+func githubFileHistory(ctx context.Context, client *github.Client, data UserInfo, filePath string) (FileHistory, error) {
+	fmt.Println("Получаем историю", filePath)
+
+	limit := historyMaxCommits()
+	history := FileHistory{Path: filePath}
+
+	var commits []*github.RepositoryCommit
+	opts := &github.CommitsListOptions{SHA: data.Ref, Path: filePath, ListOptions: github.ListOptions{PerPage: min(limit, 100)}}
+	for {
+		var page []*github.RepositoryCommit
+		var resp *github.Response
+		err := githubCall(ctx, data, func() (_ *github.Response, err error) {
+			page, resp, err = client.Repositories.ListCommits(ctx, data.User, data.Repo, opts)
+			return resp, err
+		})
+		if err != nil {
+			return FileHistory{}, err
+		}
+
+		commits = append(commits, page...)
+		if len(commits) >= limit {
+			history.Truncated = len(commits) > limit || resp.NextPage != 0
+			commits = commits[:limit]
+			break
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	// GitHub отдаёт коммиты от новых к старым
+	slices.Reverse(commits)
+
+	// Скачиваем состояния файла параллельно, сохраняя порядок коммитов
+	history.Revisions = make([]FileRevision, len(commits))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(traversalConcurrency())
+	for i, commit := range commits {
+		group.Go(func() error {
+			revision := FileRevision{
+				Commit: commit.GetSHA(),
+				Date:   commit.GetCommit().GetCommitter().GetDate().Time,
+			}
+
+			file, err := githubDownloadAt(groupCtx, client, filePath, commit.GetSHA(), data)
+			if err != nil {
+				var respErr *github.ErrorResponse
+				if !errors.As(err, &respErr) || respErr.Response.StatusCode != http.StatusNotFound {
+					return err
+				}
+				revision.Deleted = true
+				file = DepFile{Name: path.Base(filePath), Path: filePath}
+			}
+			revision.File = file
+
+			history.Revisions[i] = revision
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return FileHistory{}, err
+	}
+
+	return history, nil
+}
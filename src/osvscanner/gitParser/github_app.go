@@ -0,0 +1,196 @@
+package gitParser
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v62/github"
+)
+
+// Время жизни JWT приложения. GitHub допускает не более 10 минут.
+const githubAppJWTLifetime = 9 * time.Minute
+
+// Запас до истечения токена установки, после которого он запрашивается заново
+const githubInstallationTokenMargin = time.Minute
+
+// GitHub App, от имени которого работает воркер
+type githubApp struct {
+	id  string
+	key *rsa.PrivateKey
+}
+
+// Токен установки приложения и время его истечения
+type githubInstallationToken struct {
+	token   string
+	expires time.Time
+}
+
+// Токены установки, закэшированные до истечения, по адресу инстанса, владельцу и имени репозитория
+var githubInstallationTokens = struct {
+	sync.Mutex
+	byRepo map[string]githubInstallationToken
+}{byRepo: map[string]githubInstallationToken{}}
+
+// Загрузить настройки GitHub App из GITHUB_APP_ID и GITHUB_APP_PRIVATE_KEY (или GITHUB_APP_PRIVATE_KEY_PATH).
+// Если приложение не настроено, возвращает nil.
+var loadGitHubApp = sync.OnceValues(func() (*githubApp, error) {
+	id := os.Getenv("GITHUB_APP_ID")
+	if id == "" {
+		return nil, nil
+	}
+
+	keyPEM := strings.ReplaceAll(os.Getenv("GITHUB_APP_PRIVATE_KEY"), `\n`, "\n")
+	if keyPEM == "" {
+		keyPath := os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH")
+		if keyPath == "" {
+			return nil, fmt.Errorf("не указан приватный ключ GitHub App")
+		}
+
+		content, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать приватный ключ GitHub App: %w", err)
+		}
+		keyPEM = string(content)
+	}
+
+	key, err := parseRSAPrivateKey([]byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("некорректный приватный ключ GitHub App: %w", err)
+	}
+
+	return &githubApp{id: id, key: key}, nil
+})
+
+// Разобрать RSA-ключ в формате PKCS#1 (его выдаёт GitHub) или PKCS#8
+func parseRSAPrivateKey(keyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("ключ не в формате PEM")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("ключ не является RSA-ключом")
+	}
+
+	return rsaKey, nil
+}
+
+// Подписать JWT приложения (RS256).
+// Время выпуска сдвигается на минуту назад на случай расхождения часов с GitHub.
+func (app *githubApp) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(githubAppJWTLifetime).Unix(),
+		"iss": app.id,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, app.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Получить токен установки приложения с доступом только к сканируемому репозиторию.
+// Если репозиторий не указан, токен выдаётся на все репозитории установки у организации или пользователя.
+// Токен кэшируется и запрашивается заново незадолго до истечения.
+func (app *githubApp) installationToken(ctx context.Context, data UserInfo) (string, error) {
+	// JWT и токен установки отправляются только на инстанс из настроек воркера, а не на адрес из запроса
+	data.Url = ""
+	key := gitHubURL(data) + " " + strings.ToLower(data.User+"/"+data.Repo)
+
+	githubInstallationTokens.Lock()
+	cached, ok := githubInstallationTokens.byRepo[key]
+	githubInstallationTokens.Unlock()
+	if ok && time.Until(cached.expires) > githubInstallationTokenMargin {
+		return cached.token, nil
+	}
+
+	jwt, err := app.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+	client, err := newGitHubAPIClient(data, jwt)
+	if err != nil {
+		return "", err
+	}
+
+	installation, err := app.findInstallation(ctx, client, data)
+	if err != nil {
+		var respErr *github.ErrorResponse
+		if errors.As(err, &respErr) && respErr.Response.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("GitHub App не установлен для %s", strings.TrimSuffix(data.User+"/"+data.Repo, "/"))
+		}
+		return "", fmt.Errorf("не удалось найти установку GitHub App: %w", githubError(data, err))
+	}
+
+	opts := &github.InstallationTokenOptions{}
+	if data.Repo != "" {
+		opts.Repositories = []string{data.Repo}
+	}
+	token, _, err := client.Apps.CreateInstallationToken(ctx, installation.GetID(), opts)
+	if err != nil {
+		return "", fmt.Errorf("не удалось получить токен установки GitHub App: %w", githubError(data, err))
+	}
+
+	githubInstallationTokens.Lock()
+	githubInstallationTokens.byRepo[key] = githubInstallationToken{token: token.GetToken(), expires: token.GetExpiresAt().Time}
+	githubInstallationTokens.Unlock()
+
+	return token.GetToken(), nil
+}
+
+// Проверка, что репозиторий находится на инстансе, для которого настроен GitHub App (GITHUB_URL или github.com)
+func githubAppServes(data UserInfo) bool {
+	return data.Url == "" || strings.TrimSuffix(data.Url, "/") == gitHubURL(UserInfo{})
+}
+
+// Найти установку приложения для репозитория, а если он не указан - для организации или пользователя
+func (app *githubApp) findInstallation(ctx context.Context, client *github.Client, data UserInfo) (*github.Installation, error) {
+	if data.Repo != "" {
+		installation, _, err := client.Apps.FindRepositoryInstallation(ctx, data.User, data.Repo)
+		return installation, err
+	}
+
+	installation, _, err := client.Apps.FindOrganizationInstallation(ctx, data.User)
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response.StatusCode == http.StatusNotFound {
+		installation, _, err = client.Apps.FindUserInstallation(ctx, data.User)
+	}
+
+	return installation, err
+}
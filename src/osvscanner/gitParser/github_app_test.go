@@ -0,0 +1,126 @@
+package gitParser
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Фейковый GitHub Enterprise Server, который выдаёт токен установки приложения.
+// Каждый запрос токена проверяет подпись JWT и увеличивает счётчик.
+func newFakeGitHubApp(t *testing.T, key *rsa.PrivateKey, issued *int) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jwt, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		if !ok || len(parts) != 3 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature) != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/owner/repo/installation":
+			json.NewEncoder(w).Encode(map[string]any{"id": 42})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/app/installations/42/access_tokens":
+			var opts struct {
+				Repositories []string `json:"repositories"`
+			}
+			json.NewDecoder(r.Body).Decode(&opts)
+			if len(opts.Repositories) != 1 || opts.Repositories[0] != "repo" {
+				t.Errorf("токен запрошен для репозиториев %v", opts.Repositories)
+			}
+
+			*issued++
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]any{
+				"token":      "ghs_installation",
+				"expires_at": time.Now().Add(time.Hour).Format(time.RFC3339),
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGitHubAppInstallationToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issued := 0
+	server := newFakeGitHubApp(t, key, &issued)
+	defer server.Close()
+
+	// Адрес из запроса не должен получить JWT приложения
+	evil := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("запрос к адресу из запроса: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer evil.Close()
+
+	t.Setenv("GITHUB_URL", server.URL)
+	app := &githubApp{id: "123", key: key}
+	data := UserInfo{Url: evil.URL, User: "owner", Repo: "repo"}
+
+	for i := 0; i < 2; i++ {
+		token, err := app.installationToken(context.Background(), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "ghs_installation" {
+			t.Errorf("получен токен %q", token)
+		}
+	}
+
+	// Второй вызов берёт токен из кэша
+	if issued != 1 {
+		t.Errorf("токен запрошен %d раз", issued)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := &githubApp{id: "123", key: otherKey}
+	if _, err := other.installationToken(context.Background(), UserInfo{User: "owner", Repo: "other"}); err == nil {
+		t.Error("токен выдан по JWT, подписанному чужим ключом")
+	}
+}
+
+func TestGitHubAppServes(t *testing.T) {
+	t.Setenv("GITHUB_URL", "https://github.example.com/")
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"", true},
+		{"https://github.example.com", true},
+		{"https://github.example.com/", true},
+		{"https://attacker.example.com", false},
+		{"https://github.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := githubAppServes(UserInfo{Url: tt.url}); got != tt.want {
+			t.Errorf("githubAppServes(%q) = %v, ожидалось %v", tt.url, got, tt.want)
+		}
+	}
+}
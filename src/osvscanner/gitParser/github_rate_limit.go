@@ -0,0 +1,141 @@
+package gitParser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v62/github"
+)
+
+// Максимальное время ожидания восстановления лимита.
+// Если ждать дольше, сканирование прерывается и его нужно повторить позже.
+const maxRateLimitWait = 5 * time.Minute
+
+// Ожидание при вторичном лимите, если GitHub не указал Retry-After
+const defaultSecondaryRateLimitWait = time.Minute
+
+// Максимальное кол-во повторов запроса при превышении лимита
+const maxRateLimitRetries = 3
+
+// Ошибка исчерпания лимита запросов, после которой сканирование нужно повторить позже
+type RateLimitError struct {
+	Reset time.Time // Время, после которого лимит восстановится
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("исчерпан лимит запросов к GitHub API, лимит восстановится в %s", e.Reset.Format(time.RFC3339))
+}
+
+// Последнее известное состояние лимита в рамках одного сканирования
+type githubRateLimits struct {
+	mu    sync.Mutex
+	rates map[string]github.Rate
+}
+
+type githubRateLimitsKey struct{}
+
+// Начать отслеживание лимитов запросов для сканирования.
+// Состояние хранится в контексте и освобождается вместе с ним после окончания сканирования.
+func WithRateLimits(ctx context.Context) context.Context {
+	return context.WithValue(ctx, githubRateLimitsKey{}, &githubRateLimits{rates: map[string]github.Rate{}})
+}
+
+// Ключ состояния лимита: хэш токена, чтобы сам токен не хранился в памяти дольше запроса.
+// Без переданного токена (токен установки GitHub App или анонимный доступ) лимит отслеживается по репозиторию.
+func githubRateLimitKey(data UserInfo) string {
+	if data.Token != "" {
+		hash := sha256.Sum256([]byte(data.Token))
+		return hex.EncodeToString(hash[:])
+	}
+
+	return gitHubURL(data) + " " + strings.ToLower(data.User+"/"+data.Repo)
+}
+
+// Запомнить состояние лимита из ответа GitHub
+func updateGitHubRateLimit(ctx context.Context, data UserInfo, rate github.Rate) {
+	limits, ok := ctx.Value(githubRateLimitsKey{}).(*githubRateLimits)
+	if !ok || rate.Limit == 0 {
+		return
+	}
+
+	limits.mu.Lock()
+	defer limits.mu.Unlock()
+	limits.rates[githubRateLimitKey(data)] = rate
+}
+
+// Получить остаток лимита запросов после сканирования.
+// Возвращает false, если сервис не сообщает лимит, запросов ещё не было
+// или контекст создан без WithRateLimits.
+func RateLimitRemaining(ctx context.Context, service string, data UserInfo) (int, bool) {
+	limits, ok := ctx.Value(githubRateLimitsKey{}).(*githubRateLimits)
+	if service != "github" || !ok {
+		return 0, false
+	}
+
+	limits.mu.Lock()
+	defer limits.mu.Unlock()
+	rate, ok := limits.rates[githubRateLimitKey(data)]
+	if !ok {
+		return 0, false
+	}
+
+	return rate.Remaining, true
+}
+
+// Подождать, пока не истечёт время или не отменится контекст
+func sleepContext(ctx context.Context, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Выполнить запрос к GitHub API с ожиданием восстановления лимита.
+// Основной лимит восстанавливается ко времени из X-RateLimit-Reset, вторичный - через Retry-After.
+func githubCall(ctx context.Context, data UserInfo, call func() (*github.Response, error)) error {
+	for attempt := 0; ; attempt++ {
+		resp, err := call()
+		if resp != nil {
+			updateGitHubRateLimit(ctx, data, resp.Rate)
+		}
+		if err == nil {
+			return nil
+		}
+
+		var wait time.Duration
+		var rateErr *github.RateLimitError
+		var abuseErr *github.AbuseRateLimitError
+		switch {
+		case errors.As(err, &rateErr):
+			updateGitHubRateLimit(ctx, data, rateErr.Rate)
+			wait = time.Until(rateErr.Rate.Reset.Time) + time.Second
+		case errors.As(err, &abuseErr):
+			wait = abuseErr.GetRetryAfter()
+			if wait <= 0 {
+				wait = defaultSecondaryRateLimitWait
+			}
+		default:
+			return githubError(data, err)
+		}
+
+		if attempt >= maxRateLimitRetries || wait > maxRateLimitWait {
+			return &RateLimitError{Reset: time.Now().Add(wait)}
+		}
+
+		fmt.Println("Превышен лимит запросов к GitHub API, ждём", wait.Round(time.Second))
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package gitParser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v62/github"
+)
+
+func TestGitHubRateLimitsPerScan(t *testing.T) {
+	data := UserInfo{Token: "ghp_secret", User: "owner", Repo: "repo"}
+
+	if key := githubRateLimitKey(data); strings.Contains(key, data.Token) {
+		t.Errorf("ключ лимита содержит токен: %s", key)
+	}
+
+	scan := WithRateLimits(context.Background())
+	updateGitHubRateLimit(scan, data, github.Rate{Limit: 5000, Remaining: 4321})
+
+	if remaining, ok := RateLimitRemaining(scan, "github", data); !ok || remaining != 4321 {
+		t.Errorf("получен остаток %d, %v", remaining, ok)
+	}
+
+	// Другое сканирование не видит лимиты первого
+	if _, ok := RateLimitRemaining(WithRateLimits(context.Background()), "github", data); ok {
+		t.Error("лимит сохранился между сканированиями")
+	}
+	if _, ok := RateLimitRemaining(scan, "gitlab", data); ok {
+		t.Error("лимит возвращён для сервиса без лимитов")
+	}
+}
+
+// Фейковый GitHub, который первым ответом сообщает о превышении лимита, а затем отвечает успешно
+func newRateLimitedGitHub(t *testing.T, limited func(w http.ResponseWriter)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			limited(w)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.Write([]byte(`{"id": 1, "name": "repo"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+// Ответ об исчерпании основного лимита, который восстановится в reset
+func primaryRateLimit(reset time.Time) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+	}
+}
+
+func TestGitHubCallRateLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		limited      func(w http.ResponseWriter)
+		wantRequests int32
+		wantRetry    bool
+	}{
+		{"лимит скоро восстановится", primaryRateLimit(time.Now().Add(time.Second)), 2, true},
+		{"лимит восстановится нескоро", primaryRateLimit(time.Now().Add(time.Hour)), 1, false},
+		{"вторичный лимит с Retry-After", func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "You have exceeded a secondary rate limit", "documentation_url": "https://docs.github.com/rest/overview/rate-limits-for-the-rest-api#about-secondary-rate-limits"}`))
+		}, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newRateLimitedGitHub(t, tt.limited)
+			data := UserInfo{Url: server.URL, Token: "token", User: "owner", Repo: "repo"}
+			client, err := newGitHubAPIClient(data, data.Token)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := WithRateLimits(context.Background())
+			err = githubCall(ctx, data, func() (*github.Response, error) {
+				_, resp, err := client.Repositories.Get(ctx, data.User, data.Repo)
+				return resp, err
+			})
+
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("выполнено %d запросов, ожидалось %d", got, tt.wantRequests)
+			}
+
+			if !tt.wantRetry {
+				var rateErr *RateLimitError
+				if !errors.As(err, &rateErr) {
+					t.Fatalf("ожидалась ошибка RateLimitError, получена %v", err)
+				}
+				if time.Until(rateErr.Reset) < maxRateLimitWait {
+					t.Errorf("в ошибке указано время восстановления %s", rateErr.Reset)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if remaining, ok := RateLimitRemaining(ctx, "github", data); !ok || remaining != 4999 {
+				t.Errorf("после повтора получен остаток лимита %d, %v", remaining, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package gitParser
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v62/github"
+)
+
+// Максимальная вложенность подмодулей по умолчанию
+const defaultSubmoduleDepth = 2
+
+// Подмодуль из .gitmodules
+type gitSubmodule struct {
+	Path string
+	Url  string
+}
+
+// Получить максимальную вложенность подмодулей.
+// Задаётся переменной окружения SUBMODULE_DEPTH, 0 отключает сканирование подмодулей.
+func submoduleDepth() int {
+	depth, err := strconv.Atoi(os.Getenv("SUBMODULE_DEPTH"))
+	if err != nil || depth < 0 {
+		return defaultSubmoduleDepth
+	}
+
+	return depth
+}
+
+// Разобрать .gitmodules
+func parseGitModules(content string) []gitSubmodule {
+	var submodules []gitSubmodule
+	var current *gitSubmodule
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			current = nil
+			if strings.HasPrefix(line, "[submodule") {
+				submodules = append(submodules, gitSubmodule{})
+				current = &submodules[len(submodules)-1]
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found || current == nil {
+			continue
+		}
+
+		switch strings.TrimSpace(key) {
+		case "path":
+			current.Path = strings.Trim(strings.TrimSpace(value), "/")
+		case "url":
+			current.Url = strings.TrimSpace(value)
+		}
+	}
+
+	return submodules
+}
+
+// Определить владельца и имя репозитория подмодуля на том же инстансе GitHub.
+// Относительные адреса считаются от адреса основного репозитория.
+func githubSubmoduleRepo(data UserInfo, rawURL string) (string, string, bool) {
+	var host, repoPath string
+	switch {
+	case strings.HasPrefix(rawURL, "./") || strings.HasPrefix(rawURL, "../"):
+		repoPath = path.Join("/"+data.User+"/"+data.Repo, rawURL)
+	case strings.Contains(rawURL, "://"):
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return "", "", false
+		}
+		host, repoPath = parsed.Hostname(), parsed.Path
+	default:
+		// scp-подобный адрес: git@host:owner/repo.git
+		userHost, sshPath, found := strings.Cut(rawURL, ":")
+		if !found {
+			return "", "", false
+		}
+		_, host, _ = strings.Cut(userHost, "@")
+		if host == "" {
+			host = userHost
+		}
+		repoPath = sshPath
+	}
+
+	if host != "" {
+		instance, err := url.Parse(gitHubURL(data))
+		if err != nil || !strings.EqualFold(host, instance.Hostname()) {
+			return "", "", false
+		}
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimSuffix(repoPath, ".git"), "/"), "/")
+	if len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+		return "", "", false
+	}
+
+	return segments[0], segments[1], true
+}
+
+// Получить коммит, на который указывает подмодуль.
+// В полном дереве он есть среди элементов типа commit, иначе запрашивается через Contents API.
+func githubSubmoduleCommit(ctx context.Context, client *github.Client, data UserInfo, tree *github.Tree, submodulePath string) (string, error) {
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "commit" && entry.GetPath() == submodulePath {
+			return entry.GetSHA(), nil
+		}
+	}
+	if !tree.GetTruncated() {
+		return "", nil
+	}
+
+	content, err := githubDownload(ctx, client, github.RepositoryContent{Path: github.String(submodulePath)}, data)
+	if err != nil {
+		return "", err
+	}
+	if content.GetType() != "submodule" {
+		return "", nil
+	}
+
+	return content.GetSHA(), nil
+}
+
+// Получить файлы подмодулей репозитория.
+// Подмодули с того же инстанса сканируются рекурсивно с теми же учётными данными, пути файлов
+// дополняются путём подмодуля. Недоступные подмодули и подмодули с других сервисов пропускаются.
+func githubSubmoduleFiles(ctx context.Context, client *github.Client, data UserInfo, filter pathFilter, tree *github.Tree, depth int) ([]DepFile, error) {
+	if depth >= submoduleDepth() {
+		return nil, nil
+	}
+
+	hasGitModules := tree.GetTruncated()
+	for _, entry := range tree.Entries {
+		if entry.GetPath() == ".gitmodules" {
+			hasGitModules = true
+		}
+	}
+	if !hasGitModules {
+		return nil, nil
+	}
+
+	gitModules, err := githubDownload(ctx, client, github.RepositoryContent{Path: github.String(".gitmodules")}, data)
+	if err != nil {
+		var respErr *github.ErrorResponse
+		if errors.As(err, &respErr) && respErr.Response.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	content, err := gitModules.GetContent()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []DepFile
+	for _, submodule := range parseGitModules(content) {
+		if submodule.Path == "" || !filter.allowDir(submodule.Path) {
+			continue
+		}
+
+		owner, repo, ok := githubSubmoduleRepo(data, submodule.Url)
+		if !ok {
+			fmt.Println("Подмодуль", submodule.Path, "находится вне текущего инстанса GitHub ("+submodule.Url+"), пропускаем")
+			continue
+		}
+
+		commit, err := githubSubmoduleCommit(ctx, client, data, tree, submodule.Path)
+		if err != nil {
+			return nil, err
+		}
+		if commit == "" {
+			fmt.Println("Подмодуль", submodule.Path, "отсутствует в дереве репозитория, пропускаем")
+			continue
+		}
+
+		fmt.Println("Сканируем подмодуль", submodule.Path, "("+owner+"/"+repo+"@"+commit+")")
+		submoduleData := data
+		submoduleData.User, submoduleData.Repo, submoduleData.Ref = owner, repo, commit
+
+		submoduleFiles, err := githubGetRepoFiles(ctx, submoduleData, filter.under(submodule.Path), depth+1)
+		if err != nil {
+			var rateErr *RateLimitError
+			if errors.As(err, &rateErr) || ctx.Err() != nil {
+				return nil, err
+			}
+			fmt.Println("Не удалось просканировать подмодуль", submodule.Path+":", err)
+			continue
+		}
+
+		for _, file := range submoduleFiles {
+			file.Path = path.Join(submodule.Path, file.Path)
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
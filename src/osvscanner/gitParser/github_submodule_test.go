@@ -0,0 +1,72 @@
+package gitParser
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseGitModules(t *testing.T) {
+	content := `
+# Комментарий
+[submodule "libs/core"]
+	path = libs/core/
+	url = ../core.git
+[core]
+	path = ignored
+; Ещё комментарий
+[submodule "web"]
+	url = https://github.com/owner/web.git?ref=main
+	path=web
+[submodule "empty"]
+`
+
+	want := []gitSubmodule{
+		{Path: "libs/core", Url: "../core.git"},
+		{Path: "web", Url: "https://github.com/owner/web.git?ref=main"},
+		{},
+	}
+
+	if got := parseGitModules(content); !slices.Equal(got, want) {
+		t.Errorf("получены подмодули %+v, ожидались %+v", got, want)
+	}
+}
+
+func TestGitHubSubmoduleRepo(t *testing.T) {
+	t.Setenv("GITHUB_URL", "")
+	data := UserInfo{User: "owner", Repo: "repo"}
+	enterprise := UserInfo{User: "owner", Repo: "repo", Url: "https://git.example.com"}
+
+	tests := []struct {
+		name      string
+		data      UserInfo
+		url       string
+		wantOwner string
+		wantRepo  string
+		wantOk    bool
+	}{
+		{"соседний репозиторий", data, "../lib.git", "owner", "lib", true},
+		{"репозиторий другого владельца", data, "../../other/lib", "other", "lib", true},
+		{"относительный путь внутрь репозитория", data, "./lib", "", "", false},
+		{"относительный путь выше корня", data, "../../../lib", "", "", false},
+		{"https", data, "https://github.com/other/lib.git", "other", "lib", true},
+		{"https с другим регистром хоста", data, "https://GitHub.com/other/lib", "other", "lib", true},
+		{"ssh", data, "ssh://git@github.com/other/lib.git", "other", "lib", true},
+		{"scp", data, "git@github.com:other/lib.git", "other", "lib", true},
+		{"scp без пользователя", data, "github.com:other/lib.git", "other", "lib", true},
+		{"другой хост", data, "https://gitlab.com/other/lib.git", "", "", false},
+		{"другой хост scp", data, "git@gitlab.com:other/lib.git", "", "", false},
+		{"GitHub Enterprise", enterprise, "git@git.example.com:other/lib.git", "other", "lib", true},
+		{"github.com из GitHub Enterprise", enterprise, "https://github.com/other/lib.git", "", "", false},
+		{"лишние сегменты пути", data, "https://github.com/other/group/lib.git", "", "", false},
+		{"локальный путь", data, "/srv/git/lib.git", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, repo, ok := githubSubmoduleRepo(tt.data, tt.url)
+			if owner != tt.wantOwner || repo != tt.wantRepo || ok != tt.wantOk {
+				t.Errorf("получено %q, %q, %v, ожидалось %q, %q, %v", owner, repo, ok, tt.wantOwner, tt.wantRepo, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package gitParser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v62/github"
+)
+
+// Фейковый GitHub Enterprise Server с репозиторием owner/repo на ветке main.
+// Считает запросы к API, а дерево может отдавать обрезанным, как у очень больших репозиториев.
+type fakeGitHub struct {
+	*httptest.Server
+
+	files     map[string]string // Содержимое файлов репозитория по пути
+	truncated bool              // Отдавать дерево с truncated: true
+	tooLarge  string            // Путь файла, который Contents API отдаёт без содержимого, а Git Data API не отдаёт вовсе
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func newFakeGitHub(t *testing.T, files map[string]string) *fakeGitHub {
+	t.Helper()
+
+	fake := &fakeGitHub{files: files}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Close)
+
+	return fake
+}
+
+// SHA blob-объекта - сам путь файла, так его проще найти
+func (fake *fakeGitHub) handle(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	fake.requests = append(fake.requests, r.URL.Path)
+	fake.mu.Unlock()
+
+	const repo = "/api/v3/repos/owner/repo"
+	switch {
+	case r.URL.Path == repo+"/git/trees/main":
+		var entries []map[string]string
+		dirs := map[string]bool{}
+		for filePath := range fake.files {
+			entries = append(entries, map[string]string{"path": filePath, "type": "blob", "sha": filePath})
+			for dir := path.Dir(filePath); dir != "." && !dirs[dir]; dir = path.Dir(dir) {
+				dirs[dir] = true
+				entries = append(entries, map[string]string{"path": dir, "type": "tree"})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"sha": "main", "tree": entries, "truncated": fake.truncated})
+	case strings.HasPrefix(r.URL.Path, repo+"/git/blobs/"):
+		blobPath := strings.TrimPrefix(r.URL.Path, repo+"/git/blobs/")
+		if blobPath == fake.tooLarge {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "This API returns blobs up to 100 MB in size"}`))
+			return
+		}
+		content, ok := fake.files[blobPath]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(content))
+	case strings.HasPrefix(r.URL.Path, repo+"/contents"):
+		fake.contents(w, strings.Trim(strings.TrimPrefix(r.URL.Path, repo+"/contents"), "/"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// Ответ Contents API: файл с содержимым или список элементов папки
+func (fake *fakeGitHub) contents(w http.ResponseWriter, contentPath string) {
+	if fake.tooLarge != "" && contentPath == fake.tooLarge {
+		json.NewEncoder(w).Encode(map[string]string{
+			"type":     "file",
+			"name":     path.Base(contentPath),
+			"path":     contentPath,
+			"sha":      contentPath,
+			"encoding": "none",
+			"content":  "",
+		})
+		return
+	}
+	if content, ok := fake.files[contentPath]; ok {
+		json.NewEncoder(w).Encode(map[string]string{
+			"type":     "file",
+			"name":     path.Base(contentPath),
+			"path":     contentPath,
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+		return
+	}
+
+	items := map[string]map[string]string{}
+	for filePath := range fake.files {
+		rel := filePath
+		if contentPath != "" {
+			var ok bool
+			if rel, ok = strings.CutPrefix(filePath, contentPath+"/"); !ok {
+				continue
+			}
+		}
+
+		name, _, isDir := strings.Cut(rel, "/")
+		itemType := "file"
+		if isDir {
+			itemType = "dir"
+		}
+		items[name] = map[string]string{"type": itemType, "name": name, "path": path.Join(contentPath, name)}
+	}
+	if len(items) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	list := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		list = append(list, item)
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func TestGitHubGetFiles(t *testing.T) {
+	files := map[string]string{
+		"package-lock.json":        `{"lockfileVersion":3,"packages":{}}`,
+		"backend/requirements.txt": "flask==2.0.0\n",
+		"backend/main.py":          "print()",
+		"README.md":                "readme",
+	}
+
+	tests := []struct {
+		name      string
+		truncated bool
+		requests  int
+	}{
+		// Дерево и два blob-объекта подходящих файлов
+		{"полное дерево", false, 3},
+		// Дерево, две папки, два файла и .gitmodules, который может быть в обрезанной части дерева
+		{"обрезанное дерево", true, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeGitHub(t, files)
+			fake.truncated = tt.truncated
+
+			got, err := GetFilesFromRepository(context.Background(), "github", UserInfo{Url: fake.URL, User: "owner", Repo: "repo", Token: "token", Ref: "main"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			contents := map[string]string{}
+			for _, file := range got {
+				contents[file.Path] = file.Content
+			}
+			if len(contents) != 2 || contents["package-lock.json"] != files["package-lock.json"] || contents["backend/requirements.txt"] != files["backend/requirements.txt"] {
+				t.Errorf("получены файлы %v", contents)
+			}
+			if len(fake.requests) != tt.requests {
+				t.Errorf("выполнено %d запросов, ожидалось %d: %v", len(fake.requests), tt.requests, fake.requests)
+			}
+		})
+	}
+}
+
+func TestGitHubGetFilesBlobError(t *testing.T) {
+	files := map[string]string{
+		"package-lock.json":        "",
+		"backend/requirements.txt": "flask==2.0.0\n",
+	}
+
+	for _, truncated := range []bool{false, true} {
+		fake := newFakeGitHub(t, files)
+		fake.truncated = truncated
+		fake.tooLarge = "package-lock.json"
+
+		got, err := GetFilesFromRepository(context.Background(), "github", UserInfo{Url: fake.URL, User: "owner", Repo: "repo", Token: "token", Ref: "main"})
+		if err != nil {
+			t.Fatalf("truncated %v: %v", truncated, err)
+		}
+
+		errs := map[string]error{}
+		for _, file := range got {
+			errs[file.Path] = file.Error
+		}
+
+		// У источника указана причина, по которой GitHub не отдал файл
+		var respErr *github.ErrorResponse
+		if len(errs) != 2 || errs["backend/requirements.txt"] != nil || !errors.As(errs["package-lock.json"], &respErr) || respErr.Response.StatusCode != http.StatusForbidden {
+			t.Errorf("truncated %v: получены ошибки файлов %v", truncated, errs)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package gitParser
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v62/github"
+)
+
+// Адрес GitLab по умолчанию, если не указан ни в запросе, ни в GITLAB_URL
+const gitLabDefaultURL = "https://gitlab.com"
+
+// Максимальное кол-во элементов на страницу, которое отдаёт GitLab
+const gitLabPerPage = 100
+
+// Элемент дерева репозитория в GitLab
+type gitLabTreeItem struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+// Получить адрес проекта в API GitLab.
+// Владелец может содержать подгруппы, поэтому путь проекта кодируется целиком.
+func gitLabProjectURL(data UserInfo) string {
+	baseURL := data.Url
+	if baseURL == "" {
+		baseURL = os.Getenv("GITLAB_URL")
+	}
+	if baseURL == "" {
+		baseURL = gitLabDefaultURL
+	}
+
+	return strings.TrimSuffix(baseURL, "/") + "/api/v4/projects/" + url.PathEscape(data.User+"/"+data.Repo)
+}
+
+// Заголовки авторизации. Personal и project access token передаются одинаково.
+func gitLabHeaders(data UserInfo) map[string]string {
+	if data.Token == "" {
+		return nil
+	}
+
+	return map[string]string{"PRIVATE-TOKEN": data.Token}
+}
+
+// Получить SHA коммита, на который указывает ref
+func GitLabResolveRef(ctx context.Context, data UserInfo) (RefInfo, error) {
+	ref := data.Ref
+	if ref == "" {
+		var project struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if _, err := restGetJSON(ctx, gitLabProjectURL(data), gitLabHeaders(data), &project); err != nil {
+			return RefInfo{}, err
+		}
+		ref = project.DefaultBranch
+	}
+
+	var commit struct {
+		ID string `json:"id"`
+	}
+	if _, err := restGetJSON(ctx, gitLabProjectURL(data)+"/repository/commits/"+url.PathEscape(ref), gitLabHeaders(data), &commit); err != nil {
+		return RefInfo{}, err
+	}
+
+	return RefInfo{Ref: ref, Commit: commit.ID}, nil
+}
+
+// Получить содержимое папки
+func GitLabGetContents(ctx context.Context, path string, data UserInfo) (Directory, error) {
+	fmt.Println("Пробуем получить содержимое из \"" + path + "\"")
+
+	var files []github.RepositoryContent
+	var folders []github.RepositoryContent
+
+	// GitLab отдаёт дерево постранично
+	page := "1"
+	for page != "" {
+		query := url.Values{}
+		query.Set("per_page", strconv.Itoa(gitLabPerPage))
+		query.Set("page", page)
+		if trimmed := strings.Trim(path, "/"); trimmed != "" {
+			query.Set("path", trimmed)
+		}
+		if data.Ref != "" {
+			query.Set("ref", data.Ref)
+		}
+
+		var items []gitLabTreeItem
+		header, err := restGetJSON(ctx, gitLabProjectURL(data)+"/repository/tree?"+query.Encode(), gitLabHeaders(data), &items)
+		if err != nil {
+			return Directory{}, err
+		}
+
+		for _, item := range items {
+			switch item.Type {
+			case "blob":
+				files = append(files, github.RepositoryContent{
+					Type: github.String("file"),
+					Name: github.String(item.Name),
+					Path: github.String(item.Path),
+				})
+			case "tree":
+				folders = append(folders, github.RepositoryContent{
+					Type: github.String("dir"),
+					Name: github.String(item.Name),
+					Path: github.String(item.Path),
+				})
+			}
+		}
+
+		page = header.Get("X-Next-Page")
+	}
+
+	return Directory{folders: folders, files: files}, nil
+}
+
+// Получить содержимое файла.
+func GitLabDownload(ctx context.Context, file github.RepositoryContent, data UserInfo) (github.RepositoryContent, error) {
+	fmt.Println("Пробуем скачать ", file.GetPath())
+
+	query := url.Values{}
+	if data.Ref != "" {
+		query.Set("ref", data.Ref)
+	}
+
+	rawURL := gitLabProjectURL(data) + "/repository/files/" + url.PathEscape(file.GetPath()) + "/raw"
+	if len(query) > 0 {
+		rawURL += "?" + query.Encode()
+	}
+
+	content, _, err := restGet(ctx, rawURL, gitLabHeaders(data))
+	if err != nil {
+		return github.RepositoryContent{}, err
+	}
+
+	return github.RepositoryContent{
+		Type:    github.String("file"),
+		Name:    github.String(file.GetName()),
+		Path:    github.String(file.GetPath()),
+		Content: github.String(string(content)),
+	}, nil
+}
@@ -0,0 +1,115 @@
+package gitParser
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Фейковый GitLab: проект group/sub/project с двумя lock-файлами на ветке dev,
+// дерево корня отдаётся в две страницы
+func newFakeGitLab(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	const project = "/api/v4/projects/group%2Fsub%2Fproject"
+	tree := map[string][]gitLabTreeItem{
+		"": {
+			{Name: "package-lock.json", Type: "blob", Path: "package-lock.json"},
+			{Name: "README.md", Type: "blob", Path: "README.md"},
+			{Name: "backend", Type: "tree", Path: "backend"},
+		},
+		"backend": {
+			{Name: "requirements.txt", Type: "blob", Path: "backend/requirements.txt"},
+		},
+	}
+	files := map[string]string{
+		"package-lock.json":        `{"lockfileVersion":3,"packages":{"":{},"node_modules/lodash":{"version":"4.17.21"}}}`,
+		"backend/requirements.txt": "flask==2.0.0\n",
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		path := r.URL.EscapedPath()
+		query := r.URL.Query()
+		switch {
+		case path == project+"/repository/tree":
+			if query.Get("ref") != "dev" {
+				t.Errorf("дерево запрошено с ref %q", query.Get("ref"))
+			}
+			items := tree[query.Get("path")]
+			// Первая страница - первый элемент, вторая - остальные
+			if query.Get("page") == "1" && len(items) > 1 {
+				w.Header().Set("X-Next-Page", "2")
+				items = items[:1]
+			} else if query.Get("page") == "2" {
+				items = items[1:]
+			}
+			json.NewEncoder(w).Encode(items)
+		case strings.HasPrefix(path, project+"/repository/files/") && strings.HasSuffix(path, "/raw"):
+			if query.Get("ref") != "dev" {
+				t.Errorf("файл запрошен с ref %q", query.Get("ref"))
+			}
+			filePath := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v4/projects/group/sub/project/repository/files/"), "/raw")
+			content, ok := files[filePath]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(content))
+		case path == project+"/repository/commits/dev":
+			json.NewEncoder(w).Encode(map[string]string{"id": "0123456789abcdef0123456789abcdef01234567"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGitLabGetFiles(t *testing.T) {
+	server := newFakeGitLab(t)
+	defer server.Close()
+
+	user := UserInfo{Url: server.URL, User: "group/sub", Repo: "project", Token: "secret", Ref: "dev"}
+
+	files, err := GetFilesFromRepository(context.Background(), "gitlab", user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	for _, file := range files {
+		got[file.Path] = file.Content
+	}
+	if len(got) != 2 || !strings.Contains(got["package-lock.json"], "lodash") || got["backend/requirements.txt"] != "flask==2.0.0\n" {
+		t.Errorf("получены файлы %v", got)
+	}
+}
+
+func TestGitLabResolveRef(t *testing.T) {
+	server := newFakeGitLab(t)
+	defer server.Close()
+
+	ref, err := ResolveRef(context.Background(), "gitlab", UserInfo{Url: server.URL, User: "group/sub", Repo: "project", Token: "secret", Ref: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Ref != "dev" || ref.Commit != "0123456789abcdef0123456789abcdef01234567" {
+		t.Errorf("получен ref %+v", ref)
+	}
+}
+
+func TestGitLabUnauthorized(t *testing.T) {
+	server := newFakeGitLab(t)
+	defer server.Close()
+
+	_, err := GetFilesFromRepository(context.Background(), "gitlab", UserInfo{Url: server.URL, User: "group/sub", Repo: "project", Ref: "dev"})
+	if err == nil {
+		t.Fatal("ожидалась ошибка без токена")
+	}
+}
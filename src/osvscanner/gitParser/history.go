@@ -0,0 +1,60 @@
+package gitParser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Максимальное кол-во последних коммитов, просматриваемых для каждого lock-файла, по умолчанию
+const defaultHistoryMaxCommits = 100
+
+// Состояние lock-файла после одного из коммитов
+type FileRevision struct {
+	Commit  string
+	Date    time.Time
+	File    DepFile
+	Deleted bool // Файл удалён в этом коммите
+}
+
+// История изменений lock-файла
+type FileHistory struct {
+	Path      string
+	Revisions []FileRevision // От старых коммитов к новым
+	Truncated bool           // Просмотрены не все коммиты, файл мог меняться и раньше
+}
+
+type getHistoryFunc func(ctx context.Context, data UserInfo) ([]FileHistory, error)
+
+// Сервисы, которые умеют получать историю изменений lock-файлов
+var gitGetHistory = map[string]getHistoryFunc{
+	"github": GitHubGetHistory,
+}
+
+// Получить ограничение на кол-во коммитов в истории каждого lock-файла.
+// Задаётся переменной окружения HISTORY_MAX_COMMITS.
+func historyMaxCommits() int {
+	limit, err := strconv.Atoi(os.Getenv("HISTORY_MAX_COMMITS"))
+	if err != nil || limit < 1 {
+		return defaultHistoryMaxCommits
+	}
+
+	return limit
+}
+
+// Проверка, умеет ли git-сервис получать историю lock-файлов
+func CanGetHistory(service string) bool {
+	return gitGetHistory[service] != nil
+}
+
+// Получить историю изменений lock-файлов, которые есть в репозитории в состоянии ref
+func GetLockfileHistory(ctx context.Context, service string, user UserInfo) ([]FileHistory, error) {
+	getHistory := gitGetHistory[service]
+	if getHistory == nil {
+		return nil, fmt.Errorf("сервис %s не поддерживает получение истории файлов", service)
+	}
+
+	return getHistory(ctx, user)
+}
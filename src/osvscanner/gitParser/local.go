@@ -0,0 +1,99 @@
+package gitParser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var ErrLocalScanDisabled = errors.New("сканирование локальных папок отключено: не задана переменная окружения LOCAL_SCAN_ROOT")
+var ErrLocalPathOutsideRoot = errors.New("путь находится за пределами LOCAL_SCAN_ROOT")
+
+// Получить абсолютный путь до папки внутри разрешённого корня.
+// Путь указывается относительно LOCAL_SCAN_ROOT, выйти за его пределы (в т.ч. через символические ссылки) нельзя.
+func resolveLocalPath(path string) (string, error) {
+	root := os.Getenv("LOCAL_SCAN_ROOT")
+	if root == "" {
+		return "", ErrLocalScanDisabled
+	}
+
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Join(root, filepath.Clean("/"+path)))
+	if err != nil {
+		return "", err
+	}
+
+	if dir != root && !strings.HasPrefix(dir, root+string(filepath.Separator)) {
+		return "", ErrLocalPathOutsideRoot
+	}
+
+	return dir, nil
+}
+
+// Получить файлы из папки на диске, например из уже загруженного на агент сборки репозитория
+func LocalGetFiles(ctx context.Context, data UserInfo) ([]DepFile, error) {
+	dir, err := resolveLocalPath(data.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Пробуем получить содержимое из \"" + dir + "\"")
+
+	return walkLocalDir(dir, newPathFilter(data))
+}
+
+// Обход локальной папки с возвратом подходящих файлов
+func walkLocalDir(root string, filter pathFilter) ([]DepFile, error) {
+	files := make([]DepFile, 0)
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if entry.IsDir() {
+			if entry.Name() == ".git" || (relPath != "." && !filter.allowDir(relPath)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Символические ссылки пропускаем, чтобы не выйти за пределы папки
+		if !entry.Type().IsRegular() || !slices.Contains(allowedFiles, entry.Name()) || !filter.allowFile(relPath) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, DepFile{
+			Name:    entry.Name(),
+			Path:    relPath,
+			Content: string(content),
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
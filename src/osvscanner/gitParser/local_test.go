@@ -0,0 +1,95 @@
+package gitParser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveLocalPath(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(root, "project", "etc"), outside} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "project"), filepath.Join(root, "alias")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LOCAL_SCAN_ROOT", root)
+
+	// Корень сравнивается после раскрытия ссылок, как и в resolveLocalPath
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{"папка внутри корня", "project", filepath.Join(realRoot, "project"), nil},
+		{"пустой путь", "", realRoot, nil},
+		// ".." и абсолютные пути отсчитываются от корня, поэтому папки outside внутри него не находится
+		{"выход через ..", "../outside", "", os.ErrNotExist},
+		{"выход через .. внутри пути", "project/../../outside", "", os.ErrNotExist},
+		{"абсолютный путь", "/project/etc", filepath.Join(realRoot, "project", "etc"), nil},
+		{"абсолютный путь за пределами корня", outside, "", os.ErrNotExist},
+		{"ссылка наружу", "escape", "", ErrLocalPathOutsideRoot},
+		{"ссылка внутри корня", "alias", filepath.Join(realRoot, "project"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveLocalPath(tt.path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ожидалась ошибка %v, получена %v (путь %q)", tt.wantErr, err, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("получен путь %q, ожидался %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalGetFilesSkipsSymlinks(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(base, "requirements.txt")
+	if err := os.WriteFile(secret, []byte("secret==1.0.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "package-lock.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(root, "requirements.txt")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LOCAL_SCAN_ROOT", root)
+
+	files, err := LocalGetFiles(context.Background(), UserInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Path != "package-lock.json" {
+		t.Errorf("получены файлы %+v", files)
+	}
+}
@@ -0,0 +1,284 @@
+package gitParser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"web-scan-worker/src/osvscanner/models"
+
+	"golang.org/x/exp/maps"
+)
+
+const BunEcosystem = NpmEcosystem
+
+// Проект (workspace) из bun.lock, ключ "" - корень репозитория
+type BunLockWorkspace struct {
+	Name                 string            `json:"name"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// Сведения о зависимостях пакета (объект внутри массива пакета)
+type BunLockPackageInfo struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// Пакет из bun.lock: массив вида ["name@version", "registry", {...}, "integrity"],
+// состав которого зависит от источника пакета
+type BunLockPackage struct {
+	Resolution string
+	Info       BunLockPackageInfo
+}
+
+func (pkg *BunLockPackage) UnmarshalJSON(data []byte) error {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return err
+	}
+	if len(elements) == 0 {
+		return fmt.Errorf("пустое описание пакета")
+	}
+
+	if err := json.Unmarshal(elements[0], &pkg.Resolution); err != nil {
+		return err
+	}
+
+	// Сведения о зависимостях - первый объект после resolution
+	for _, element := range elements[1:] {
+		if strings.HasPrefix(strings.TrimSpace(string(element)), "{") {
+			return json.Unmarshal(element, &pkg.Info)
+		}
+	}
+
+	return nil
+}
+
+type BunLockfile struct {
+	Version    int                         `json:"lockfileVersion"`
+	Workspaces map[string]BunLockWorkspace `json:"workspaces"`
+	Packages   map[string]BunLockPackage   `json:"packages"`
+}
+
+// Привести JSONC к JSON: убрать комментарии и висячие запятые, не трогая содержимое строк
+func stripJSONC(content string) []byte {
+	result := make([]byte, 0, len(content))
+	inString := false
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+
+		if inString {
+			result = append(result, c)
+			if c == '\\' && i+1 < len(content) {
+				i++
+				result = append(result, content[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			result = append(result, c)
+		case strings.HasPrefix(content[i:], "//"):
+			end := strings.IndexByte(content[i:], '\n')
+			if end == -1 {
+				return result
+			}
+			i += end - 1
+		case strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end == -1 {
+				return result
+			}
+			i += end + 3
+		case c == ',':
+			// Запятая перед закрывающей скобкой не допускается в JSON
+			next := skipJSONCSpace(content[i+1:])
+			if !strings.HasPrefix(next, "}") && !strings.HasPrefix(next, "]") {
+				result = append(result, c)
+			}
+		default:
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
+// Пропустить пробелы и комментарии до следующего значимого символа
+func skipJSONCSpace(content string) string {
+	for {
+		content = strings.TrimLeft(content, " \t\r\n")
+
+		switch {
+		case strings.HasPrefix(content, "//"):
+			end := strings.IndexByte(content, '\n')
+			if end == -1 {
+				return ""
+			}
+			content = content[end:]
+		case strings.HasPrefix(content, "/*"):
+			end := strings.Index(content[2:], "*/")
+			if end == -1 {
+				return ""
+			}
+			content = content[end+4:]
+		default:
+			return content
+		}
+	}
+}
+
+// Разбор resolution вида "name@version", "@scope/name@version" или "name@workspace:path"
+func parseBunResolution(resolution string) (string, string) {
+	i := strings.LastIndex(resolution[min(1, len(resolution)):], "@") + 1
+	if i == 0 {
+		return resolution, ""
+	}
+
+	return resolution[:i], resolution[i+1:]
+}
+
+// Разбить ключ пакета на имена пакетов, через которые он установлен: "a/@scope/b/c" -> ["a", "@scope/b", "c"]
+func splitBunPackageKey(key string) []string {
+	var names []string
+
+	parts := strings.Split(key, "/")
+	for i := 0; i < len(parts); i++ {
+		if strings.HasPrefix(parts[i], "@") && i+1 < len(parts) {
+			names = append(names, parts[i]+"/"+parts[i+1])
+			i++
+			continue
+		}
+		names = append(names, parts[i])
+	}
+
+	return names
+}
+
+// Найти ключ пакета, в который разрешается зависимость name пакета parent.
+// Как и в node_modules, сначала ищется вложенная установка, затем установки уровнем выше.
+func resolveBunDependency(packages map[string]BunLockPackage, parent, name string) (string, bool) {
+	var names []string
+	if parent != "" {
+		names = splitBunPackageKey(parent)
+	}
+
+	for i := len(names); i >= 0; i-- {
+		key := strings.Join(append(names[:i:i], name), "/")
+		if _, ok := packages[key]; ok {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+// Определить группы пакетов: в bun.lock флагов dev и optional нет, они вычисляются по графу зависимостей
+func bunPackageGroups(lockfile BunLockfile) map[string]lockDepGroup {
+	walk := func(withDev, withOptional bool) map[string]bool {
+		reached := map[string]bool{}
+		var queue []string
+
+		push := func(parent string, dependencies map[string]string) {
+			for name := range dependencies {
+				key, ok := resolveBunDependency(lockfile.Packages, parent, name)
+				if ok && !reached[key] {
+					reached[key] = true
+					queue = append(queue, key)
+				}
+			}
+		}
+
+		for workspacePath, workspace := range lockfile.Workspaces {
+			// Зависимости участников workspaces могут быть установлены внутри них
+			parent := ""
+			if workspacePath != "" {
+				parent = workspace.Name
+			}
+
+			push(parent, workspace.Dependencies)
+			push(parent, workspace.PeerDependencies)
+			if withDev {
+				push(parent, workspace.DevDependencies)
+			}
+			if withOptional {
+				push(parent, workspace.OptionalDependencies)
+			}
+		}
+
+		for len(queue) > 0 {
+			key := queue[0]
+			queue = queue[1:]
+			info := lockfile.Packages[key].Info
+
+			push(key, info.Dependencies)
+			push(key, info.PeerDependencies)
+			if withOptional {
+				push(key, info.OptionalDependencies)
+			}
+		}
+
+		return reached
+	}
+
+	production := walk(false, true)
+	required := walk(true, false)
+	productionRequired := walk(false, false)
+
+	groups := map[string]lockDepGroup{}
+	for key := range lockfile.Packages {
+		groups[key] = newLockDepGroup(productionRequired[key], production[key], required[key])
+	}
+
+	return groups
+}
+
+// Парсинг bun.lock (текстовый lock-файл Bun в формате JSONC)
+func ParseBunLock(depFile DepFile) ([]models.PackageDetails, error) {
+	var lockfile BunLockfile
+
+	err := json.Unmarshal(stripJSONC(depFile.Content), &lockfile)
+	if err != nil {
+		return []models.PackageDetails{}, fmt.Errorf("could not extract from %s: %w", depFile.Path, err)
+	}
+
+	packageGroups := bunPackageGroups(lockfile)
+
+	groups := map[string]lockDepGroup{}
+	details := map[string]models.PackageDetails{}
+
+	for key, pkg := range lockfile.Packages {
+		name, version := parseBunResolution(pkg.Resolution)
+
+		// Пакеты не из реестра npm (workspace:, link:, file:, git, архивы) не сканируем
+		if version == "" || strings.Contains(version, ":") {
+			continue
+		}
+
+		// Один и тот же пакет может быть установлен в нескольких местах, объединяем группы
+		detailsKey := name + "@" + version
+		group := packageGroups[key]
+		if existing, ok := groups[detailsKey]; ok {
+			group = existing.merge(group)
+		}
+		groups[detailsKey] = group
+
+		details[detailsKey] = models.PackageDetails{
+			Name:      name,
+			Version:   version,
+			Ecosystem: BunEcosystem,
+			CompareAs: BunEcosystem,
+			DepGroups: group.depGroups(),
+		}
+	}
+
+	return maps.Values(details), nil
+}
@@ -0,0 +1,38 @@
+package gitParser
+
+import "testing"
+
+func TestParseBunLock(t *testing.T) {
+	packages, err := ParseBunLock(readFixture(t, "bun/bun.lock", "bun.lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectPackages(t, packages, []string{
+		"@babel/code-frame@7.22.13",
+		"chalk@2.4.2",
+		"chalk@4.1.2",
+		"fsevents@2.3.3 optional",
+		"string-width@4.2.3",
+		"typescript@5.2.2 dev",
+		"wrap@1.0.0",
+	})
+}
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"a": [1, 2,],}`, `{"a": [1, 2]}`},
+		{"{\"a\": 1, // комментарий\n}", "{\"a\": 1 \n}"},
+		{`{"a": "x, // /* y */",}`, `{"a": "x, // /* y */"}`},
+		{`{"a": "\"",/* c */}`, `{"a": "\""}`},
+	}
+
+	for _, tt := range tests {
+		if got := string(stripJSONC(tt.input)); got != tt.want {
+			t.Errorf("stripJSONC(%q) = %q, ожидалось %q", tt.input, got, tt.want)
+		}
+	}
+}
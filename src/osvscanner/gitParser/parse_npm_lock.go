@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path"
+	"slices"
 	"strings"
 	"web-scan-worker/src/osvscanner/models"
 
@@ -39,6 +40,30 @@ type NpmLockPackage struct {
 	Optional    bool `json:"optional,omitempty"`
 
 	Link bool `json:"link,omitempty"`
+
+	// Шаблоны папок workspaces (только у корневого пакета, повторяет поле из package.json)
+	Workspaces NpmWorkspaces `json:"workspaces,omitempty"`
+}
+
+// Поле workspaces: массив шаблонов или объект {"packages": [...]} (формат yarn)
+type NpmWorkspaces []string
+
+func (w *NpmWorkspaces) UnmarshalJSON(data []byte) error {
+	var patterns []string
+	if err := json.Unmarshal(data, &patterns); err == nil {
+		*w = patterns
+		return nil
+	}
+
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*w = object.Packages
+
+	return nil
 }
 
 type NpmLockfile struct {
@@ -130,12 +155,20 @@ func extractNpmPackageName(name string) string {
 	return pkgName
 }
 
-// Парсинг пакетов lock-файла для npm версии 2+
+// Парсинг пакетов lock-файла для npm версии 2+.
+// Сами участники workspaces и ссылки на них пакетами не считаются, а у остальных пакетов
+// указываются workspaces, которые от них зависят.
 func parseNpmLockPackages(packages map[string]NpmLockPackage) map[string]models.PackageDetails {
 	details := map[string]models.PackageDetails{}
 
+	members := npmWorkspaceMembers(packages)
+	attribution := npmWorkspaceAttribution(packages, members)
+
 	for namePath, detail := range packages {
-		if namePath == "" {
+		if namePath == "" || detail.Link {
+			continue
+		}
+		if _, ok := members[namePath]; ok {
 			continue
 		}
 
@@ -146,19 +179,114 @@ func parseNpmLockPackages(packages map[string]NpmLockPackage) map[string]models.
 			finalName = extractNpmPackageName(namePath)
 		}
 
+		// Один и тот же пакет может быть установлен в нескольких местах, объединяем их workspaces
+		key := finalName + "@" + detail.Version
+		workspaces := append(slices.Clone(details[key].Workspaces), attribution[namePath]...)
+		slices.Sort(workspaces)
+
 		// Собираем информацию о пакете в объект
-		details[finalName+"@"+detail.Version] = models.PackageDetails{
-			Name:      finalName,
-			Version:   detail.Version,
-			Ecosystem: NpmEcosystem,
-			CompareAs: NpmEcosystem,
-			DepGroups: detail.depGroups(),
+		details[key] = models.PackageDetails{
+			Name:       finalName,
+			Version:    detail.Version,
+			Ecosystem:  NpmEcosystem,
+			CompareAs:  NpmEcosystem,
+			DepGroups:  detail.depGroups(),
+			Workspaces: slices.Compact(workspaces),
 		}
 	}
 
 	return details
 }
 
+// Найти участников workspaces: путь до папки -> имя пакета.
+// Участники определяются по шаблонам workspaces корневого пакета и по ссылкам на локальные папки.
+func npmWorkspaceMembers(packages map[string]NpmLockPackage) map[string]string {
+	members := map[string]string{}
+
+	addMember := func(memberPath string) {
+		name := packages[memberPath].Name
+		if name == "" {
+			name = memberPath
+		}
+		members[memberPath] = name
+	}
+
+	for namePath := range packages {
+		if namePath != "" && !strings.Contains(namePath, "node_modules/") && matchAny(packages[""].Workspaces, namePath) {
+			addMember(namePath)
+		}
+	}
+
+	for _, detail := range packages {
+		if detail.Link && !strings.HasPrefix(detail.Resolved, "..") {
+			if _, ok := packages[detail.Resolved]; ok {
+				addMember(detail.Resolved)
+			}
+		}
+	}
+
+	return members
+}
+
+// Найти установленный пакет так же, как это делает Node.js: в node_modules папки
+// зависимого пакета и далее вверх до корня. Ссылки заменяются на папку, на которую они указывают.
+func resolveNpmDependency(packages map[string]NpmLockPackage, from string, name string) (string, bool) {
+	dir := from
+	for {
+		candidate := path.Join(dir, "node_modules", name)
+		if detail, ok := packages[candidate]; ok {
+			if detail.Link {
+				_, ok := packages[detail.Resolved]
+				return detail.Resolved, ok
+			}
+			return candidate, true
+		}
+
+		if dir == "" {
+			return "", false
+		}
+
+		// Поднимаемся на уровень пакета, в node_modules которого лежит текущий
+		i := strings.LastIndex(dir, "node_modules/")
+		if i < 0 {
+			dir = ""
+		} else {
+			dir = strings.TrimSuffix(dir[:i], "/")
+		}
+	}
+}
+
+// Для каждого установленного пакета найти workspaces, которые прямо или транзитивно от него зависят
+func npmWorkspaceAttribution(packages map[string]NpmLockPackage, members map[string]string) map[string][]string {
+	attribution := map[string][]string{}
+
+	for memberPath, memberName := range members {
+		visited := map[string]bool{memberPath: true}
+		queue := []string{memberPath}
+
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+
+			detail := packages[current]
+			for _, dependencies := range []map[string]string{detail.Dependencies, detail.DevDependencies, detail.OptionalDependencies, detail.PeerDependencies} {
+				for name := range dependencies {
+					resolved, ok := resolveNpmDependency(packages, current, name)
+					if !ok || visited[resolved] {
+						continue
+					}
+
+					visited[resolved] = true
+					queue = append(queue, resolved)
+					attribution[resolved] = append(attribution[resolved], memberName)
+				}
+			}
+		}
+	}
+
+	return attribution
+}
+
 // Парсинг npm lock-файла
 func parseNpmLock(lockfile NpmLockfile) map[string]models.PackageDetails {
 	// Если lock-файл версии 2+
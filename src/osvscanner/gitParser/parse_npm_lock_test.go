@@ -0,0 +1,94 @@
+package gitParser
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestParseNpmLockWorkspaces(t *testing.T) {
+	file := readFixture(t, "npm/workspaces.json", "package-lock.json")
+
+	packages, err := ParseNpmLock(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Участники workspaces и ссылки на них пакетами не считаются
+	expectPackages(t, packages, []string{
+		"express@4.18.2",
+		"fsevents@2.3.3 optional",
+		"js-tokens@4.0.0",
+		"lodash@3.10.1",
+		"lodash@4.17.21",
+		"loose-envify@1.4.0",
+		"ms@2.0.0",
+		"ms@2.1.3",
+		"react@18.2.0",
+		"typescript@5.2.2 dev",
+	})
+
+	want := map[string][]string{
+		// Транзитивные зависимости
+		"react@18.2.0":       {"@app/web"},
+		"loose-envify@1.4.0": {"@app/web"},
+		"js-tokens@4.0.0":    {"@app/web"},
+		// Вложенный node_modules участника перекрывает корневой
+		"lodash@4.17.21": {"@app/web"},
+		"lodash@3.10.1":  {"@app/api"},
+		// Вложенный node_modules зависимости
+		"express@4.18.2": {"@app/api"},
+		"ms@2.0.0":       {"@app/api"},
+		// Зависимость участника, подключённого ссылкой, вне шаблонов workspaces
+		"ms@2.1.3": {"@app/shared", "@app/web"},
+		// Зависимости корневого пакета и лишние пакеты ни к одному workspace не относятся
+		"typescript@5.2.2": nil,
+		"fsevents@2.3.3":   nil,
+	}
+
+	got := map[string][]string{}
+	for _, pkg := range packages {
+		got[pkg.Name+"@"+pkg.Version] = pkg.Workspaces
+	}
+	if !maps.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("получены workspaces %v, ожидались %v", got, want)
+	}
+}
+
+func TestResolveNpmDependency(t *testing.T) {
+	var lockfile NpmLockfile
+	if err := json.Unmarshal([]byte(readFixture(t, "npm/workspaces.json", "package-lock.json").Content), &lockfile); err != nil {
+		t.Fatal(err)
+	}
+
+	wantMembers := map[string]string{
+		"packages/api": "@app/api",
+		"packages/web": "@app/web",
+		"libs/shared":  "@app/shared",
+	}
+	if members := npmWorkspaceMembers(lockfile.Packages); !maps.Equal(members, wantMembers) {
+		t.Errorf("получены участники workspaces %v, ожидались %v", members, wantMembers)
+	}
+
+	tests := []struct {
+		from string
+		name string
+		want string
+	}{
+		{"packages/api", "lodash", "packages/api/node_modules/lodash"},
+		{"packages/web", "lodash", "node_modules/lodash"},
+		{"packages/web", "@app/shared", "libs/shared"},
+		{"node_modules/express", "ms", "node_modules/express/node_modules/ms"},
+		{"node_modules/express/node_modules/ms", "lodash", "node_modules/lodash"},
+		{"libs/shared", "ms", "node_modules/ms"},
+		{"packages/web", "left-pad", ""},
+	}
+
+	for _, tt := range tests {
+		got, ok := resolveNpmDependency(lockfile.Packages, tt.from, tt.name)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("resolveNpmDependency(%q, %q) = %q, %v, ожидалось %q", tt.from, tt.name, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package gitParser
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"web-scan-worker/src/internal/cachedregexp"
+	"web-scan-worker/src/osvscanner/models"
+
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+)
+
+// Lock-файлы, которые фиксируют версии зависимостей из package.json
+var npmLockfiles = []string{"package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml", "bun.lock"}
+
+type NpmPackageJson struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+
+	Workspaces NpmWorkspaces `json:"workspaces"`
+}
+
+// Получить минимальную версию, подходящую под диапазон версий npm:
+// "^1.2.3", "~1.2", ">=1.2.3 <2", "1.x", "1.2.3 - 2.0.0". Для первого варианта из "a || b".
+// Если нижней границы нет ("*", "<2", "latest"), возвращается пустая строка.
+func npmRangeMinVersion(constraint string) string {
+	constraint, _, _ = strings.Cut(constraint, "||")
+
+	fields := strings.Fields(constraint)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// Оператор может быть отделён от версии пробелом: ">= 1.2.3"
+	lower := fields[0]
+	if strings.Trim(lower, "<>=~^") == "" && len(fields) > 1 {
+		lower += fields[1]
+	}
+
+	// Только верхняя граница или строгая нижняя - минимальную версию не определить
+	if strings.HasPrefix(lower, "<") || (strings.HasPrefix(lower, ">") && !strings.HasPrefix(lower, ">=")) {
+		return ""
+	}
+
+	re := cachedregexp.MustCompile(`^[>=~^v]*(\d+)(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?(-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?$`)
+	matched := re.FindStringSubmatch(lower)
+	if matched == nil {
+		return ""
+	}
+
+	parts := matched[1:4]
+	for i, part := range parts {
+		if part == "" || part == "x" || part == "X" || part == "*" {
+			parts[i] = "0"
+		}
+	}
+
+	version := strings.Join(parts, ".")
+	// Пре-релиз имеет смысл только у полной версии
+	if matched[3] != "" {
+		version += matched[4]
+	}
+
+	return version
+}
+
+// Парсинг объявленной зависимости: псевдонимы "npm:name@range" заменяются на настоящий пакет,
+// зависимости не из реестра (git, ссылки, локальные папки) пропускаются
+func parseNpmDeclaration(name, constraint string) (string, string, bool) {
+	if alias, ok := strings.CutPrefix(constraint, "npm:"); ok {
+		i := strings.LastIndex(alias[min(1, len(alias)):], "@") + 1
+		if i == 0 {
+			return alias, "", true
+		}
+		name, constraint = alias[:i], alias[i+1:]
+	}
+
+	if strings.Contains(constraint, ":") || strings.Contains(constraint, "/") {
+		return "", "", false
+	}
+
+	return name, npmRangeMinVersion(constraint), true
+}
+
+// Парсинг package.json без lock-файла.
+// Точные версии неизвестны, поэтому для каждой зависимости берётся минимальная версия объявленного диапазона.
+func ParseNpmPackageJson(depFile DepFile) ([]models.PackageDetails, error) {
+	var manifest NpmPackageJson
+
+	err := json.Unmarshal([]byte(depFile.Content), &manifest)
+	if err != nil {
+		return []models.PackageDetails{}, fmt.Errorf("could not extract from %s: %w", depFile.Path, err)
+	}
+
+	details := map[string]models.PackageDetails{}
+
+	// Если зависимость объявлена в нескольких секциях, приоритет у обычных зависимостей
+	sections := []struct {
+		dependencies map[string]string
+		depGroups    []string
+	}{
+		{manifest.DevDependencies, []string{"dev"}},
+		{manifest.OptionalDependencies, []string{"optional"}},
+		{manifest.Dependencies, nil},
+	}
+
+	for _, section := range sections {
+		for name, constraint := range section.dependencies {
+			pkgName, version, ok := parseNpmDeclaration(name, constraint)
+			if !ok {
+				continue
+			}
+
+			// Без нижней границы ("*", "latest", "<2") проверять нечего: OSV без версии вернёт все уязвимости пакета
+			if version == "" {
+				fmt.Println("Не удалось определить минимальную версию пакета", pkgName, "("+constraint+") в", depFile.Path)
+				continue
+			}
+
+			details[name] = models.PackageDetails{
+				Name:      pkgName,
+				Version:   version,
+				Ecosystem: NpmEcosystem,
+				CompareAs: NpmEcosystem,
+				DepGroups: section.depGroups,
+			}
+		}
+	}
+
+	return maps.Values(details), nil
+}
+
+// Убрать package.json, версии зависимостей которых зафиксированы lock-файлом в той же папке,
+// а также package.json участников workspaces такого проекта: они входят в его lock-файл.
+// Участники берутся из поля workspaces в package.json (npm, yarn, bun) и из importers
+// в pnpm-lock.yaml (pnpm хранит workspaces в pnpm-workspace.yaml).
+// Сканируются только манифесты без lock-файла.
+func dropLockedManifests(files []DepFile) []DepFile {
+	lockfileDirs := map[string]bool{}
+	for _, file := range files {
+		if slices.Contains(npmLockfiles, file.Name) {
+			lockfileDirs[path.Dir(file.Path)] = true
+		}
+	}
+
+	// Шаблоны workspaces закреплённых проектов относительно их папок
+	workspaces := map[string][]string{}
+	for _, file := range files {
+		dir := path.Dir(file.Path)
+		switch {
+		case IsManifest(file.Name) && lockfileDirs[dir]:
+			var manifest NpmPackageJson
+			if err := json.Unmarshal([]byte(file.Content), &manifest); err == nil {
+				workspaces[dir] = append(workspaces[dir], manifest.Workspaces...)
+			}
+		case file.Name == "pnpm-lock.yaml":
+			// Ключи importers - пути участников относительно папки lock-файла
+			var lockfile struct {
+				Importers map[string]yaml.Node `yaml:"importers"`
+			}
+			if err := yaml.Unmarshal([]byte(file.Content), &lockfile); err == nil {
+				workspaces[dir] = append(workspaces[dir], maps.Keys(lockfile.Importers)...)
+			}
+		}
+	}
+
+	return slices.DeleteFunc(files, func(file DepFile) bool {
+		if !IsManifest(file.Name) {
+			return false
+		}
+
+		dir := path.Dir(file.Path)
+		if lockfileDirs[dir] {
+			return true
+		}
+
+		for rootDir, patterns := range workspaces {
+			memberPath, err := filepath.Rel(rootDir, dir)
+			if err == nil && !strings.HasPrefix(memberPath, "..") && matchAny(patterns, filepath.ToSlash(memberPath)) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
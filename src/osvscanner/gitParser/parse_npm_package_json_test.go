@@ -0,0 +1,90 @@
+package gitParser
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseNpmPackageJson(t *testing.T) {
+	packages, err := ParseNpmPackageJson(readFixture(t, "npm/package.json", "package.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Объявления без нижней границы и не из реестра пропускаются,
+	// при объявлении в нескольких секциях приоритет у обычных зависимостей
+	expectPackages(t, packages, []string{
+		"@babel/core@7.22.0",
+		"express@4.18.0",
+		"fsevents@2.3.3 optional",
+		"jest@29.7.0 dev",
+		"lodash@4.17.0",
+		"react@17.0.2",
+		"string-width@4.2.0",
+		"typescript@5.0.0",
+	})
+}
+
+func TestNpmRangeMinVersion(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^1.2.3", "1.2.3"},
+		{"~1.2", "1.2.0"},
+		{">=1.2.3 <2", "1.2.3"},
+		{">= 1.2.3", "1.2.3"},
+		{"1.x", "1.0.0"},
+		{"1.2.3 - 2.0.0", "1.2.3"},
+		{"^2.0.0-beta.1", "2.0.0-beta.1"},
+		{"1 || 2", "1.0.0"},
+		{"*", ""},
+		{"latest", ""},
+		{"<2", ""},
+		{">1.0.0", ""},
+	}
+
+	for _, tt := range tests {
+		if got := npmRangeMinVersion(tt.constraint); got != tt.want {
+			t.Errorf("npmRangeMinVersion(%q) = %q, ожидалось %q", tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestDropLockedManifests(t *testing.T) {
+	files := []DepFile{
+		{Name: "package.json", Path: "package.json", Content: `{"workspaces": ["packages/*"]}`},
+		{Name: "package-lock.json", Path: "package-lock.json"},
+		{Name: "package.json", Path: "packages/app/package.json", Content: `{}`},
+		{Name: "package.json", Path: "packages/app/nested/package.json", Content: `{}`},
+		{Name: "package.json", Path: "tools/package.json", Content: `{}`},
+		{Name: "package.json", Path: "yarn/package.json", Content: `{"workspaces": {"packages": ["libs/**"]}}`},
+		{Name: "yarn.lock", Path: "yarn/yarn.lock"},
+		{Name: "package.json", Path: "yarn/libs/a/b/package.json", Content: `{}`},
+		{Name: "package.json", Path: "mono/package.json", Content: `{}`},
+		{Name: "pnpm-lock.yaml", Path: "mono/pnpm-lock.yaml", Content: "lockfileVersion: '9.0'\nimporters:\n  .: {}\n  libs/a/b:\n    dependencies: {}\n"},
+		{Name: "package.json", Path: "mono/libs/a/b/package.json", Content: `{}`},
+		{Name: "package.json", Path: "mono/libs/c/package.json", Content: `{}`},
+		{Name: "package.json", Path: "other/package.json", Content: `{}`},
+		{Name: "yarn.lock", Path: "other/sub/yarn.lock"},
+	}
+
+	var got []string
+	for _, file := range dropLockedManifests(files) {
+		got = append(got, file.Path)
+	}
+
+	want := []string{
+		"package-lock.json",
+		"packages/app/nested/package.json",
+		"tools/package.json",
+		"yarn/yarn.lock",
+		"mono/pnpm-lock.yaml",
+		"mono/libs/c/package.json",
+		"other/package.json",
+		"other/sub/yarn.lock",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("получены файлы %v, ожидались %v", got, want)
+	}
+}
@@ -0,0 +1,282 @@
+package gitParser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"web-scan-worker/src/osvscanner/models"
+
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+)
+
+const PnpmEcosystem = NpmEcosystem
+
+// Пакет из секций packages и snapshots
+type PnpmLockPackage struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+
+	Dependencies         map[string]string `yaml:"dependencies"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+
+	// Только в версиях <9
+	Dev      bool `yaml:"dev"`
+	Optional bool `yaml:"optional"`
+}
+
+// Версия прямой зависимости проекта: строка в версии 5 или объект {specifier, version} в версиях 6+
+type PnpmDependencyVersion string
+
+func (v *PnpmDependencyVersion) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*v = PnpmDependencyVersion(node.Value)
+		return nil
+	}
+
+	var object struct {
+		Version string `yaml:"version"`
+	}
+	if err := node.Decode(&object); err != nil {
+		return err
+	}
+	*v = PnpmDependencyVersion(object.Version)
+
+	return nil
+}
+
+// Проект (importer) в lock-файле: корень или участник workspace
+type PnpmLockImporter struct {
+	Dependencies         map[string]PnpmDependencyVersion `yaml:"dependencies"`
+	DevDependencies      map[string]PnpmDependencyVersion `yaml:"devDependencies"`
+	OptionalDependencies map[string]PnpmDependencyVersion `yaml:"optionalDependencies"`
+}
+
+type PnpmLockfile struct {
+	Version string `yaml:"lockfileVersion"`
+	// Зависимости проекта без workspaces в версиях <9
+	PnpmLockImporter `yaml:",inline"`
+	Importers        map[string]PnpmLockImporter `yaml:"importers"`
+	Packages         map[string]PnpmLockPackage  `yaml:"packages"`
+	// Версия 9+: зависимости пакетов с учётом peer-зависимостей
+	Snapshots map[string]PnpmLockPackage `yaml:"snapshots"`
+}
+
+// Группы пакета, по аналогии с npm.
+// Используется для lock-файлов, где группы вычисляются по графу зависимостей.
+type lockDepGroup struct {
+	Dev      bool
+	Optional bool
+}
+
+// Определить группы пакета по его достижимости из зависимостей проектов:
+// productionRequired - из обычных зависимостей без optional, production - из обычных и optional,
+// required - из обычных и dev без optional.
+// Пакет, нужный только dev и optional зависимостям одновременно, относится к обеим группам, как devOptional в npm.
+func newLockDepGroup(productionRequired, production, required bool) lockDepGroup {
+	switch {
+	case productionRequired:
+		return lockDepGroup{}
+	case production && required:
+		return lockDepGroup{Dev: true, Optional: true}
+	default:
+		return lockDepGroup{Dev: !production, Optional: !required}
+	}
+}
+
+// Объединить группы одного пакета, установленного в нескольких местах
+func (group lockDepGroup) merge(other lockDepGroup) lockDepGroup {
+	return lockDepGroup{Dev: group.Dev && other.Dev, Optional: group.Optional && other.Optional}
+}
+
+func (group lockDepGroup) depGroups() []string {
+	if group.Dev && group.Optional {
+		return []string{"dev", "optional"}
+	}
+	if group.Dev {
+		return []string{"dev"}
+	}
+	if group.Optional {
+		return []string{"optional"}
+	}
+
+	return nil
+}
+
+// Убрать суффикс peer-зависимостей из версии: "(react@17.0.2)" в версиях 6+ и "_react@17.0.2" в версии 5
+func trimPnpmPeerSuffix(version string, lockfileVersion float64) string {
+	version, _, _ = strings.Cut(version, "(")
+	if lockfileVersion < 6 {
+		version, _, _ = strings.Cut(version, "_")
+	}
+
+	return version
+}
+
+// Парсинг имени и версии пакета из ключа секции packages:
+// "/@babel/core/7.0.0_peer@1.0.0" (v5), "/@babel/core@7.0.0(peer@1.0.0)" (v6), "@babel/core@7.0.0" (v9)
+func parsePnpmPackageKey(key string, lockfileVersion float64) (string, string) {
+	key = strings.TrimPrefix(key, "/")
+
+	// Peer-зависимости в скобках могут содержать разделители, поэтому убираем их до поиска версии
+	key, _, _ = strings.Cut(key, "(")
+
+	separator := "@"
+	if lockfileVersion < 6 {
+		separator = "/"
+	}
+
+	// Начинаем поиск после первого символа, чтобы не спутать разделитель с "@" у scope.
+	// Суффикс "_peer" версии 5 отрезается только от версии: "_" может быть в имени пакета (string_decoder).
+	i := strings.LastIndex(key[min(1, len(key)):], separator) + 1
+	if i == 0 {
+		return key, ""
+	}
+
+	return key[:i], trimPnpmPeerSuffix(key[i+1:], lockfileVersion)
+}
+
+// Получить ключ snapshot по имени и версии зависимости. Для псевдонимов версия уже содержит имя пакета.
+func pnpmSnapshotKey(snapshots map[string]PnpmLockPackage, name, version string) (string, bool) {
+	if _, ok := snapshots[name+"@"+version]; ok {
+		return name + "@" + version, true
+	}
+	if _, ok := snapshots[version]; ok {
+		return version, true
+	}
+
+	return "", false
+}
+
+// Определить группы пакетов версии 9+, в которой флагов dev и optional нет.
+// Пакет dev, если не достижим из обычных и optional зависимостей проектов, и optional,
+// если до него можно дойти только через optional зависимости.
+func pnpmSnapshotGroups(lockfile PnpmLockfile) map[string]lockDepGroup {
+	importers := maps.Values(lockfile.Importers)
+	importers = append(importers, lockfile.PnpmLockImporter)
+
+	walk := func(withDev, withOptional bool) map[string]bool {
+		reached := map[string]bool{}
+		var queue []string
+
+		push := func(name, version string) {
+			key, ok := pnpmSnapshotKey(lockfile.Snapshots, name, version)
+			if ok && !reached[key] {
+				reached[key] = true
+				queue = append(queue, key)
+			}
+		}
+
+		for _, importer := range importers {
+			roots := []map[string]PnpmDependencyVersion{importer.Dependencies}
+			if withDev {
+				roots = append(roots, importer.DevDependencies)
+			}
+			if withOptional {
+				roots = append(roots, importer.OptionalDependencies)
+			}
+			for _, dependencies := range roots {
+				for name, version := range dependencies {
+					push(name, string(version))
+				}
+			}
+		}
+
+		for len(queue) > 0 {
+			snapshot := lockfile.Snapshots[queue[0]]
+			queue = queue[1:]
+
+			edges := []map[string]string{snapshot.Dependencies}
+			if withOptional {
+				edges = append(edges, snapshot.OptionalDependencies)
+			}
+			for _, dependencies := range edges {
+				for name, version := range dependencies {
+					push(name, version)
+				}
+			}
+		}
+
+		return reached
+	}
+
+	production := walk(false, true)
+	required := walk(true, false)
+	productionRequired := walk(false, false)
+
+	groups := map[string]lockDepGroup{}
+	for key := range lockfile.Snapshots {
+		group := newLockDepGroup(productionRequired[key], production[key], required[key])
+
+		// Пакет может встречаться в нескольких snapshots с разными peer-зависимостями
+		packageKey := trimPnpmPeerSuffix(key, 9)
+		if existing, ok := groups[packageKey]; ok {
+			group = existing.merge(group)
+		}
+		groups[packageKey] = group
+	}
+
+	return groups
+}
+
+// Парсинг pnpm-lock.yaml версий 5, 6 и 9
+func ParsePnpmLock(depFile DepFile) ([]models.PackageDetails, error) {
+	var lockfile PnpmLockfile
+
+	err := yaml.Unmarshal([]byte(depFile.Content), &lockfile)
+	if err != nil {
+		return []models.PackageDetails{}, fmt.Errorf("could not extract from %s: %w", depFile.Path, err)
+	}
+
+	lockfileVersion, err := strconv.ParseFloat(lockfile.Version, 64)
+	if err != nil {
+		return []models.PackageDetails{}, fmt.Errorf("could not extract from %s: неизвестная версия lock-файла %q", depFile.Path, lockfile.Version)
+	}
+
+	var snapshotGroups map[string]lockDepGroup
+	if lockfileVersion >= 9 {
+		snapshotGroups = pnpmSnapshotGroups(lockfile)
+	}
+
+	groups := map[string]lockDepGroup{}
+	details := map[string]models.PackageDetails{}
+
+	for key, pkg := range lockfile.Packages {
+		name, version := parsePnpmPackageKey(key, lockfileVersion)
+
+		// Для пакетов из git и архивов имя и версия указаны отдельно
+		if pkg.Name != "" {
+			name = pkg.Name
+		}
+		if pkg.Version != "" {
+			version = pkg.Version
+		}
+
+		// Локальные пакеты не сканируем
+		if strings.HasPrefix(version, "link:") || strings.HasPrefix(version, "file:") {
+			continue
+		}
+
+		group := lockDepGroup{Dev: pkg.Dev, Optional: pkg.Optional}
+		if snapshotGroups != nil {
+			group = snapshotGroups[strings.TrimPrefix(trimPnpmPeerSuffix(key, lockfileVersion), "/")]
+		}
+
+		// В версиях <9 пакет с разными peer-зависимостями записан несколько раз, объединяем группы
+		detailsKey := name + "@" + version
+		if existing, ok := groups[detailsKey]; ok {
+			group = existing.merge(group)
+		}
+		groups[detailsKey] = group
+
+		details[detailsKey] = models.PackageDetails{
+			Name:      name,
+			Version:   version,
+			Ecosystem: PnpmEcosystem,
+			CompareAs: PnpmEcosystem,
+			DepGroups: group.depGroups(),
+		}
+	}
+
+	return maps.Values(details), nil
+}
@@ -0,0 +1,74 @@
+package gitParser
+
+import "testing"
+
+func TestParsePnpmLock(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []string
+	}{
+		{
+			fixture: "pnpm/v5.yaml",
+			want: []string{
+				"@testing-library/react@14.0.0 dev",
+				"fsevents@2.3.3 dev,optional",
+				"react-dom@17.0.2",
+				"react@17.0.2",
+				"safe-buffer@5.2.1",
+				"string_decoder@1.3.0",
+				"typescript@5.2.2 dev",
+			},
+		},
+		{
+			fixture: "pnpm/v6.yaml",
+			want: []string{
+				"@testing-library/react@14.0.0 dev",
+				"gitpkg@1.0.0",
+				"react-dom@17.0.2",
+				"react@17.0.2",
+				"string_decoder@1.3.0",
+			},
+		},
+		{
+			fixture: "pnpm/v9.yaml",
+			want: []string{
+				"@types/react@18.2.0 dev",
+				"fsevents@2.3.3 optional",
+				"react-dom@17.0.2",
+				"react@17.0.2",
+				"string-width@4.2.3",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			packages, err := ParsePnpmLock(readFixture(t, tt.fixture, "pnpm-lock.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectPackages(t, packages, tt.want)
+		})
+	}
+}
+
+func TestParsePnpmPackageKey(t *testing.T) {
+	tests := []struct {
+		key             string
+		lockfileVersion float64
+		name, version   string
+	}{
+		{"/string_decoder/1.3.0", 5.4, "string_decoder", "1.3.0"},
+		{"/@babel/core/7.0.0_supports-color@8.1.1", 5.4, "@babel/core", "7.0.0"},
+		{"/@types/react_dom/1.0.0_@types+react@18.2.0", 5.4, "@types/react_dom", "1.0.0"},
+		{"/@babel/core@7.0.0(@types/node@20.0.0)", 6.0, "@babel/core", "7.0.0"},
+		{"@babel/core@7.0.0(supports-color@8.1.1)", 9.0, "@babel/core", "7.0.0"},
+	}
+
+	for _, tt := range tests {
+		name, version := parsePnpmPackageKey(tt.key, tt.lockfileVersion)
+		if name != tt.name || version != tt.version {
+			t.Errorf("parsePnpmPackageKey(%q) = %q, %q, ожидалось %q, %q", tt.key, name, version, tt.name, tt.version)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package gitParser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"web-scan-worker/src/internal/cachedregexp"
+	"web-scan-worker/src/osvscanner/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Пакет из yarn.lock формата Yarn Berry (v2+)
+type yarnBerryPackage struct {
+	Version    string `yaml:"version"`
+	Resolution string `yaml:"resolution"`
+}
+
+// Протоколы, пакеты которых находятся внутри самого проекта и не сканируются
+var yarnBerryLocalProtocols = map[string]bool{
+	"workspace": true,
+	"portal":    true,
+	"link":      true,
+}
+
+// Проверка, что yarn.lock в формате Yarn Berry: в нём есть секция __metadata
+func isYarnBerryLock(content string) bool {
+	return cachedregexp.MustCompile(`(?m)^"?__metadata"?:`).MatchString(content)
+}
+
+// Разбор строки resolution вида "<name>@<protocol>:<reference>"
+func parseYarnBerryResolution(resolution string) (string, string, string) {
+	str := resolution
+
+	isScoped := strings.HasPrefix(str, "@")
+	if isScoped {
+		str = strings.TrimPrefix(str, "@")
+	}
+
+	name, descriptor, _ := strings.Cut(str, "@")
+	protocol, reference, _ := strings.Cut(descriptor, ":")
+
+	if isScoped {
+		name = "@" + name
+	}
+
+	return name, protocol, reference
+}
+
+// Получить resolution исходного пакета из resolution патча:
+// "lodash@patch:lodash@npm%3A4.17.21#./patches/lodash.patch::version=4.17.21&hash=abc" -> "lodash@npm:4.17.21"
+func unwrapYarnBerryPatch(reference string) string {
+	base, _, _ := strings.Cut(reference, "#")
+
+	unescaped, err := url.PathUnescape(base)
+	if err != nil {
+		return base
+	}
+
+	return unescaped
+}
+
+// Парсинг yarn.lock (Berry, v2+)
+func parseYarnBerryLock(depFile DepFile) ([]models.PackageDetails, error) {
+	var parsedLockfile map[string]yarnBerryPackage
+
+	err := yaml.Unmarshal([]byte(depFile.Content), &parsedLockfile)
+	if err != nil {
+		return []models.PackageDetails{}, fmt.Errorf("could not extract from %s: %w", depFile.Path, err)
+	}
+
+	packages := make([]models.PackageDetails, 0, len(parsedLockfile))
+
+	for key, pkg := range parsedLockfile {
+		if key == "__metadata" {
+			continue
+		}
+
+		resolution := pkg.Resolution
+		if resolution == "" {
+			// Без resolution используем первый спецификатор из ключа
+			resolution, _, _ = strings.Cut(key, ",")
+		}
+
+		name, protocol, reference := parseYarnBerryResolution(resolution)
+
+		// Патченный пакет сканируется как исходная версия, патчи могут быть вложенными
+		for protocol == "patch" {
+			name, protocol, reference = parseYarnBerryResolution(unwrapYarnBerryPatch(reference))
+		}
+
+		if yarnBerryLocalProtocols[protocol] {
+			continue
+		}
+
+		version := pkg.Version
+		if version == "" && protocol == "npm" {
+			version = reference
+		}
+
+		// Без версии пакет нельзя проверить на уязвимости
+		if version == "" {
+			fmt.Println("Не удалось определить версию пакета", name, "в yarn.lock")
+			continue
+		}
+
+		packages = append(packages, models.PackageDetails{
+			Name:      name,
+			Version:   version,
+			Ecosystem: YarnEcosystem,
+			CompareAs: YarnEcosystem,
+		})
+	}
+
+	return packages, nil
+}
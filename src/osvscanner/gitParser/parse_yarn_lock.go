@@ -0,0 +1,143 @@
+package gitParser
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"web-scan-worker/src/internal/cachedregexp"
+	"web-scan-worker/src/osvscanner/models"
+)
+
+const YarnEcosystem = NpmEcosystem
+
+// Пропускаем пустые строки и комментарии
+func shouldSkipYarnLine(line string) bool {
+	line = strings.TrimSpace(line)
+
+	return line == "" || strings.HasPrefix(line, "#")
+}
+
+// Разбиваем lock-файл на группы строк, каждая из которых описывает один пакет.
+// Новая группа начинается со строки без отступа.
+func groupYarnPackageLines(scanner *bufio.Scanner) [][]string {
+	var groups [][]string
+	var group []string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if shouldSkipYarnLine(line) {
+			continue
+		}
+
+		// Начало описания нового пакета
+		if !strings.HasPrefix(line, " ") {
+			if len(group) > 0 {
+				groups = append(groups, group)
+			}
+			group = nil
+		}
+
+		group = append(group, line)
+	}
+
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// Парсинг названия пакета из заголовка группы.
+// Заголовок может содержать несколько спецификаторов через запятую, кавычки, scope
+// и псевдонимы вида "alias@npm:real-name@^1.0.0", для которых берём настоящее имя.
+func extractYarnPackageName(header string) string {
+	str := strings.TrimPrefix(header, "\"")
+	str, _, _ = strings.Cut(str, ",")
+
+	isScoped := strings.HasPrefix(str, "@")
+	if isScoped {
+		str = strings.TrimPrefix(str, "@")
+	}
+
+	name, right, _ := strings.Cut(str, "@")
+
+	// Псевдоним: берём имя пакета, на который он указывает
+	if strings.HasPrefix(right, "npm:") && strings.Contains(right, "@") {
+		return extractYarnPackageName(strings.TrimPrefix(right, "npm:"))
+	}
+
+	if isScoped {
+		name = "@" + name
+	}
+
+	return name
+}
+
+// Парсинг версии пакета из строки "  version "1.2.3""
+func determineYarnPackageVersion(group []string) string {
+	re := cachedregexp.MustCompile(`^ {2}"?version"?:? "?([\w-.+]+)"?$`)
+
+	for _, line := range group {
+		// Окончания строк CRLF и пробелы в конце строки не мешают разбору
+		matched := re.FindStringSubmatch(strings.TrimRight(line, " \t\r"))
+
+		if matched != nil {
+			return matched[1]
+		}
+	}
+
+	return ""
+}
+
+// Парсинг группы строк в пакет
+func parseYarnPackageGroup(group []string) models.PackageDetails {
+	name := extractYarnPackageName(group[0])
+	version := determineYarnPackageVersion(group)
+
+	if version == "" {
+		fmt.Println("Не удалось определить версию пакета", name, "в yarn.lock")
+	}
+
+	return models.PackageDetails{
+		Name:      name,
+		Version:   version,
+		Ecosystem: YarnEcosystem,
+		CompareAs: YarnEcosystem,
+	}
+}
+
+// Парсинг yarn.lock (classic, v1)
+func parseYarnLockV1(depFile DepFile) ([]models.PackageDetails, error) {
+	scanner := bufio.NewScanner(strings.NewReader(depFile.Content))
+	// Строки с длинными списками спецификаторов могут не поместиться в стандартный буфер
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	packageGroups := groupYarnPackageLines(scanner)
+
+	if err := scanner.Err(); err != nil {
+		return []models.PackageDetails{}, fmt.Errorf("could not extract from %s: %w", depFile.Path, err)
+	}
+
+	packages := make([]models.PackageDetails, 0, len(packageGroups))
+
+	for _, group := range packageGroups {
+		pkg := parseYarnPackageGroup(group)
+		// Без версии пакет нельзя проверить на уязвимости
+		if pkg.Version == "" {
+			continue
+		}
+		packages = append(packages, pkg)
+	}
+
+	return packages, nil
+}
+
+// Парсинг yarn.lock. Формат (classic или Berry) определяется по содержимому.
+func ParseYarnLock(depFile DepFile) ([]models.PackageDetails, error) {
+	if isYarnBerryLock(depFile.Content) {
+		return parseYarnBerryLock(depFile)
+	}
+
+	return parseYarnLockV1(depFile)
+}
@@ -0,0 +1,44 @@
+package gitParser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseYarnLock(t *testing.T) {
+	v1 := readFixture(t, "yarn/v1.lock", "yarn.lock")
+	v1CRLF := v1
+	v1CRLF.Content = strings.ReplaceAll(v1.Content, "\n", "\r\n")
+
+	tests := []struct {
+		name string
+		file DepFile
+		want []string
+	}{
+		{
+			name: "v1",
+			file: v1,
+			want: []string{"@babel/code-frame@7.22.13", "lodash@4.17.21", "string-width@4.2.3", "@types/node@20.8.10"},
+		},
+		{
+			name: "v1 с CRLF",
+			file: v1CRLF,
+			want: []string{"@babel/code-frame@7.22.13", "lodash@4.17.21", "string-width@4.2.3", "@types/node@20.8.10"},
+		},
+		{
+			name: "Berry",
+			file: readFixture(t, "yarn/berry.lock", "yarn.lock"),
+			want: []string{"@babel/code-frame@7.22.13", "lodash@4.17.21", "string-width@4.2.3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packages, err := ParseYarnLock(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectPackages(t, packages, tt.want)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package gitParser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Выполнить GET-запрос к REST API git-сервиса
+func restGet(ctx context.Context, rawURL string, headers map[string]string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка при чтении ответа от сервера: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("ошибка ответа сервера (%s): %s", resp.Status, string(body))
+	}
+
+	return body, resp.Header, nil
+}
+
+// Выполнить GET-запрос к REST API git-сервиса и декодировать JSON-ответ
+func restGetJSON(ctx context.Context, rawURL string, headers map[string]string, out any) (http.Header, error) {
+	body, header, err := restGet(ctx, rawURL, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, fmt.Errorf("ошибка при декодировании ответа от сервера: %w", err)
+	}
+
+	return header, nil
+}
+
+// Экранировать каждый сегмент пути к файлу, сохраняя разделители
+func escapePath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,150 @@
+package osvscanner
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"web-scan-worker/src/osvscanner/gitParser"
+	"web-scan-worker/src/osvscanner/models"
+	"web-scan-worker/src/osvscanner/osv"
+)
+
+// Версия пакета, для которой запрашиваются уязвимости
+type packageKey struct {
+	Name      string
+	Ecosystem models.Ecosystem
+	Version   string
+}
+
+// Провести OSV-сканирование истории lock-файлов и найти периоды, когда в них были версии пакетов,
+// подверженные уязвимости vulnID. Уязвимость ищется в том числе по её псевдонимам (например, CVE для GHSA).
+func DoHistoryScan(histories []gitParser.FileHistory, vulnID string) (models.VulnerabilityHistory, error) {
+	if osv.RequestUserAgent == "" {
+		osv.RequestUserAgent = "web-scan"
+	}
+
+	vuln, err := osv.GetWithClient(vulnID, http.DefaultClient)
+	if err != nil {
+		return models.VulnerabilityHistory{}, fmt.Errorf("%w: не удалось получить уязвимость %s: %w", ErrAPIFailed, vulnID, err)
+	}
+
+	ids := map[string]bool{vulnID: true, vuln.ID: true}
+	for _, alias := range vuln.Aliases {
+		ids[alias] = true
+	}
+
+	// Пакеты каждого состояния каждого файла. nil - состояние не удалось разобрать, оно пропускается.
+	revisionPackages := make([][][]scannedPackage, len(histories))
+	var queried []packageKey
+	seen := map[packageKey]bool{}
+	for i, history := range histories {
+		revisionPackages[i] = make([][]scannedPackage, len(history.Revisions))
+		for j, revision := range history.Revisions {
+			if revision.Deleted {
+				revisionPackages[i][j] = []scannedPackage{}
+				continue
+			}
+			if revision.File.Error != nil {
+				fmt.Println("Пропускаем", history.Path, "в коммите", revision.Commit+":", revision.File.Error)
+				continue
+			}
+
+			pkgs, err := scanLockfile(revision.File)
+			if err != nil {
+				fmt.Println("Пропускаем", history.Path, "в коммите", revision.Commit+":", err)
+				continue
+			}
+
+			revisionPackages[i][j] = filterUnscannablePackages(pkgs)
+			for _, pkg := range revisionPackages[i][j] {
+				key := packageKey{Name: pkg.Name, Ecosystem: pkg.Ecosystem, Version: pkg.Version}
+				if !seen[key] {
+					seen[key] = true
+					queried = append(queried, key)
+				}
+			}
+		}
+	}
+
+	// Каждая версия пакета запрашивается один раз, сколько бы коммитов её ни содержали
+	affected := map[packageKey]bool{}
+	if len(queried) > 0 {
+		var query osv.BatchedQuery
+		for _, key := range queried {
+			query.Queries = append(query.Queries, osv.MakePkgRequest(models.PackageDetails{
+				Name:      key.Name,
+				Version:   key.Version,
+				Ecosystem: key.Ecosystem,
+			}))
+		}
+
+		resp, err := osv.MakeRequest(query)
+		if err != nil {
+			return models.VulnerabilityHistory{}, fmt.Errorf("%w: ошибка запроса к osv.dev: %w", ErrAPIFailed, err)
+		}
+
+		for i, result := range resp.Results {
+			for _, found := range result.Vulns {
+				if ids[found.ID] {
+					affected[queried[i]] = true
+				}
+			}
+		}
+	}
+
+	result := models.VulnerabilityHistory{
+		ID:      vuln.ID,
+		Aliases: vuln.Aliases,
+		Summary: vuln.Summary,
+		Windows: []models.ExposureWindow{},
+	}
+
+	// Период начинается с коммита, в котором появилась уязвимая версия, и заканчивается коммитом, где её не стало
+	for i, history := range histories {
+		var open *models.ExposureWindow
+		for j, revision := range history.Revisions {
+			pkgs := revisionPackages[i][j]
+			if pkgs == nil {
+				continue
+			}
+
+			var vulnerable []models.PackageInfo
+			for _, pkg := range pkgs {
+				if affected[packageKey{Name: pkg.Name, Ecosystem: pkg.Ecosystem, Version: pkg.Version}] {
+					vulnerable = append(vulnerable, models.PackageInfo{
+						Name:      pkg.Name,
+						Version:   pkg.Version,
+						Ecosystem: string(pkg.Ecosystem),
+					})
+				}
+			}
+
+			switch {
+			case len(vulnerable) > 0 && open == nil:
+				open = &models.ExposureWindow{
+					Source:           models.SourceInfo{Path: history.Path, Type: "lockfile"},
+					Packages:         vulnerable,
+					IntroducedCommit: revision.Commit,
+					IntroducedAt:     revision.Date,
+					HistoryTruncated: j == 0 && history.Truncated,
+				}
+			case len(vulnerable) == 0 && open != nil:
+				fixedAt := revision.Date
+				open.FixedCommit = revision.Commit
+				open.FixedAt = &fixedAt
+				result.Windows = append(result.Windows, *open)
+				open = nil
+			}
+		}
+
+		if open != nil {
+			result.Windows = append(result.Windows, *open)
+		}
+	}
+
+	sort.Slice(result.Windows, func(i, j int) bool {
+		return result.Windows[i].IntroducedAt.Before(result.Windows[j].IntroducedAt)
+	})
+
+	return result, nil
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"slices"
 	"strings"
+	"time"
 )
 
 type VulnerabilityResults struct {
@@ -18,6 +19,7 @@ func (vulns *VulnerabilityResults) Flatten() []VulnerabilityFlattened {
 					Source:        res.Source,
 					Package:       pkg.Package,
 					DepGroups:     pkg.DepGroups,
+					Workspaces:    pkg.Workspaces,
 					Vulnerability: v,
 					GroupInfo:     getGroupInfoForVuln(pkg.Groups, v.ID),
 				})
@@ -37,6 +39,7 @@ type VulnerabilityFlattened struct {
 	Source            SourceInfo
 	Package           PackageInfo
 	DepGroups         []string
+	Workspaces        []string
 	Vulnerability     Vulnerability
 	GroupInfo         GroupInfo
 	Licenses          []License
@@ -67,6 +70,7 @@ type License string
 type PackageVulns struct {
 	Package         PackageInfo     `json:"package"`
 	DepGroups       []string        `json:"dependency_groups,omitempty"`
+	Workspaces      []string        `json:"workspaces,omitempty"`
 	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
 	Groups          []GroupInfo     `json:"groups,omitempty"`
 }
@@ -114,3 +118,38 @@ type PackageInfo struct {
 	Version   string `json:"version"`
 	Ecosystem string `json:"ecosystem"`
 }
+
+// Разница уязвимостей между двумя состояниями репозитория
+type VulnerabilityDiff struct {
+	Introduced []VulnerabilityChange `json:"introduced"`
+	Fixed      []VulnerabilityChange `json:"fixed"`
+}
+
+type VulnerabilityChange struct {
+	Source      SourceInfo  `json:"source"`
+	Package     PackageInfo `json:"package"`
+	ID          string      `json:"id"`
+	Aliases     []string    `json:"aliases,omitempty"`
+	Summary     string      `json:"summary,omitempty"`
+	MaxSeverity string      `json:"max_severity"`
+	Workspaces  []string    `json:"workspaces,omitempty"`
+}
+
+// Периоды, в течение которых репозиторий был подвержен уязвимости
+type VulnerabilityHistory struct {
+	ID      string           `json:"id"`
+	Aliases []string         `json:"aliases,omitempty"`
+	Summary string           `json:"summary,omitempty"`
+	Windows []ExposureWindow `json:"windows"`
+}
+
+// Период, в течение которого lock-файл содержал уязвимые версии пакетов
+type ExposureWindow struct {
+	Source           SourceInfo    `json:"source"`
+	Packages         []PackageInfo `json:"packages"` // Уязвимые пакеты в момент появления уязвимости
+	IntroducedCommit string        `json:"introduced_commit"`
+	IntroducedAt     time.Time     `json:"introduced_at"`
+	FixedCommit      string        `json:"fixed_commit,omitempty"` // Пусто, если уязвимость не исправлена
+	FixedAt          *time.Time    `json:"fixed_at,omitempty"`
+	HistoryTruncated bool          `json:"history_truncated,omitempty"` // Уязвимость могла появиться раньше самого старого просмотренного коммита
+}
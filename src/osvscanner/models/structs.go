@@ -3,11 +3,12 @@ package models
 type Packages []PackageDetails
 
 type PackageDetails struct {
-	Name      string    `json:"name"`
-	Version   string    `json:"version"`
-	Ecosystem Ecosystem `json:"ecosystem,omitempty"`
-	CompareAs Ecosystem `json:"compareAs,omitempty"`
-	DepGroups []string  `json:"-"`
+	Name       string    `json:"name"`
+	Version    string    `json:"version"`
+	Ecosystem  Ecosystem `json:"ecosystem,omitempty"`
+	CompareAs  Ecosystem `json:"compareAs,omitempty"`
+	DepGroups  []string  `json:"-"`
+	Workspaces []string  `json:"-"` // Workspaces монорепозитория, которые зависят от пакета
 }
 
 type Lockfile struct {
@@ -7,16 +7,15 @@ import (
 	"web-scan-worker/src/osvscanner/gitParser"
 	"web-scan-worker/src/osvscanner/models"
 	"web-scan-worker/src/osvscanner/osv"
-
-	"github.com/google/go-github/v62/github"
 )
 
 type scannedPackage struct {
-	Name      string
-	Ecosystem models.Ecosystem
-	Version   string
-	Source    models.SourceInfo
-	DepGroups []string
+	Name       string
+	Ecosystem  models.Ecosystem
+	Version    string
+	Source     models.SourceInfo
+	DepGroups  []string
+	Workspaces []string
 }
 
 var ErrAPIFailed = errors.New("ошибка API запроса")
@@ -65,13 +64,14 @@ func scanLockfile(file gitParser.DepFile) ([]scannedPackage, error) {
 	packages := make([]scannedPackage, len(parsedLockfile.Packages))
 	for i, pkgDetail := range parsedLockfile.Packages {
 		packages[i] = scannedPackage{
-			Name:      pkgDetail.Name,
-			Version:   pkgDetail.Version,
-			Ecosystem: pkgDetail.Ecosystem,
-			DepGroups: pkgDetail.DepGroups,
+			Name:       pkgDetail.Name,
+			Version:    pkgDetail.Version,
+			Ecosystem:  pkgDetail.Ecosystem,
+			DepGroups:  pkgDetail.DepGroups,
+			Workspaces: pkgDetail.Workspaces,
 			Source: models.SourceInfo{
 				Path: file.Path,
-				Type: "lockfile",
+				Type: gitParser.SourceType(file.Name),
 			},
 		}
 	}
@@ -79,20 +79,24 @@ func scanLockfile(file gitParser.DepFile) ([]scannedPackage, error) {
 	return packages, nil
 }
 
-// Провести OSV-сканирование
-func DoScan(files []github.RepositoryContent, userInfo gitParser.UserInfo) (models.VulnerabilityResults, error) {
+// Провести OSV-сканирование.
+// Файлы, которые не удалось разобрать, пропускаются, а ошибка записывается в их поле Error.
+func DoScan(files []gitParser.DepFile) (models.VulnerabilityResults, error) {
 	scannedPackages := []scannedPackage{}
 
-	for _, file := range files {
-		content, _ := file.GetContent()
-		depFile := gitParser.DepFile{
-			Name:    file.GetName(),
-			Path:    file.GetPath(),
-			Content: string(content),
+	for i, file := range files {
+		// Файл без содержимого не сканируется, ошибка сохраняется у источника
+		if file.Error != nil {
+			fmt.Println("Пропускаем файл", file.Path+":", file.Error)
+			continue
 		}
-		pkgs, err := scanLockfile(depFile)
+
+		// Один повреждённый файл не прерывает сканирование остальных
+		pkgs, err := scanLockfile(file)
 		if err != nil {
-			return models.VulnerabilityResults{}, err
+			fmt.Println("Пропускаем файл", file.Path+":", err)
+			files[i].Error = fmt.Errorf("не удалось разобрать файл: %w", err)
+			continue
 		}
 		scannedPackages = append(scannedPackages, pkgs...)
 	}
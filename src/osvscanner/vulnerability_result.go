@@ -40,6 +40,7 @@ func buildVulnerabilityResults(
 		}
 
 		pkg.DepGroups = rawPkg.DepGroups
+		pkg.Workspaces = rawPkg.Workspaces
 
 		if len(vulnsResp.Results[i].Vulns) > 0 {
 			includePackage = true